@@ -0,0 +1,211 @@
+package hyper
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hyperhq/client-go/rest"
+	hyperclient "github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/hyper-api/signature"
+
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/golang/glog"
+)
+
+// Conn calls the endpoints of the Hyper API which the hyper-api client has no call
+// for: the volumes, the fips and the info. Unlike the HyperConn of client-go, it
+// verifies the server certificate like HyperCli, against the certificate authority
+// of the cluster entry if any, unless the cluster entry sets insecure-skip-tls-verify,
+// and returns the errors the VolumeCli and FipCli of client-go exit on.
+type Conn struct {
+	host      string
+	region    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewConn returns a Conn to the host of config, signing the requests with the keys
+// of config
+func NewConn(config *rest.Config) (*Conn, error) {
+	hostURL, err := url.Parse(config.Host)
+	if err != nil {
+		return nil, fmt.Errorf("host url format error: %v", err)
+	}
+	//replace default domain
+	host := hostURL.Host
+	if strings.Contains(host, rest.DefaultDomain) {
+		host = strings.Replace(host, "*", config.Region, 1)
+		glog.V(4).Infof("NewConn: replace default domain to %v", host)
+	}
+
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig == nil {
+		tlsConfig = tlsconfig.ClientDefault()
+	}
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	return &Conn{
+		host:      host,
+		region:    config.Region,
+		accessKey: config.AccessKey,
+		secretKey: config.SecretKey,
+		client: &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				DialContext:     dialer.DialContext,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+	}, nil
+}
+
+// SockRequest sends a request to the endpoint, and returns the body and the status
+// code of the response
+func (c *Conn) SockRequest(method, endpoint string, data io.Reader, contentType string) (string, int, error) {
+	req, err := http.NewRequest(method, "https://"+c.host+endpoint, data)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not create new request: %v", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	//calculate sign4 for apirouter
+	req = signature.Sign4(c.accessKey, c.secretKey, req, c.region)
+	glog.V(7).Infof("%v %v", method, req.URL)
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("http request error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", resp.StatusCode, fmt.Errorf("read body error: %v", err)
+	}
+	return string(body), resp.StatusCode, nil
+}
+
+// request sends a request with the JSON encoded body if not nil, and decodes the
+// response into result if not nil. It fails if the response status is not status.
+func (c *Conn) request(method, endpoint string, body interface{}, status int, result interface{}) error {
+	var data io.Reader
+	contentType := ""
+	if body != nil {
+		buf, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		data, contentType = bytes.NewReader(buf), "application/json"
+	}
+	response, httpStatus, err := c.SockRequest(method, endpoint, data, contentType)
+	if err != nil {
+		return err
+	} else if httpStatus != status {
+		return &ResponseError{StatusCode: httpStatus, Body: response}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal([]byte(response), result)
+}
+
+// ResponseError is the unexpected response of a request of a Conn
+type ResponseError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("response error: %v - %v", e.StatusCode, strings.TrimSpace(e.Body))
+}
+
+// IsNotFound returns true if err is the response of a request to an object which
+// does not exist
+func IsNotFound(err error) bool {
+	responseErr, ok := err.(*ResponseError)
+	return ok && responseErr.StatusCode == http.StatusNotFound
+}
+
+// ListVolumes returns the volumes of zone, or of every zone if zone is empty
+func (c *Conn) ListVolumes(zone string) ([]hyperclient.VolumeResponse, error) {
+	volumes := []hyperclient.VolumeResponse{}
+	err := c.request("GET", "/api/v1/hyper/volumes?zone="+url.QueryEscape(zone), nil, http.StatusOK, &volumes)
+	return volumes, err
+}
+
+// GetVolume returns the volume name of zone
+func (c *Conn) GetVolume(name, zone string) (*hyperclient.VolumeResponse, error) {
+	volume := &hyperclient.VolumeResponse{}
+	err := c.request("GET", "/api/v1/hyper/volumes/"+url.PathEscape(name)+"?zone="+url.QueryEscape(zone), nil, http.StatusOK, volume)
+	return volume, err
+}
+
+type volumeCreateRequest struct {
+	Name     string `json:"name"`
+	Zone     string `json:"zone"`
+	Size     int    `json:"size,omitempty"`
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+// CreateVolume creates the volume name in zone, of size GB or from the snapshot if
+// the snapshot is set
+func (c *Conn) CreateVolume(name, zone string, size int, snapshot string) (*hyperclient.VolumeResponse, error) {
+	created := &hyperclient.VolumeResponse{}
+	body := volumeCreateRequest{Name: name, Zone: zone, Size: size, Snapshot: snapshot}
+	err := c.request("POST", "/api/v1/hyper/volumes", body, http.StatusCreated, created)
+	return created, err
+}
+
+// DeleteVolume deletes the volume name of zone
+func (c *Conn) DeleteVolume(name, zone string) error {
+	return c.request("DELETE", "/api/v1/hyper/volumes/"+url.PathEscape(name)+"?zone="+url.QueryEscape(zone), nil, http.StatusNoContent, nil)
+}
+
+// ListFips returns the fips of the tenant
+func (c *Conn) ListFips() ([]hyperclient.FipResponse, error) {
+	fips := []hyperclient.FipResponse{}
+	err := c.request("GET", "/api/v1/hyper/fips", nil, http.StatusOK, &fips)
+	return fips, err
+}
+
+// GetFip returns the fip ip
+func (c *Conn) GetFip(ip string) (*hyperclient.FipResponse, error) {
+	fip := &hyperclient.FipResponse{}
+	err := c.request("GET", "/api/v1/hyper/fips/"+url.PathEscape(ip), nil, http.StatusOK, fip)
+	return fip, err
+}
+
+// AllocateFips allocates count new fips
+func (c *Conn) AllocateFips(count int) ([]hyperclient.FipResponse, error) {
+	fips := []hyperclient.FipResponse{}
+	err := c.request("POST", fmt.Sprintf("/api/v1/hyper/fips?count=%d", count), nil, http.StatusCreated, &fips)
+	return fips, err
+}
+
+// NameFip names the fip ip
+func (c *Conn) NameFip(ip, name string) error {
+	return c.request("POST", "/api/v1/hyper/fips/"+url.PathEscape(ip), map[string]string{"name": name}, http.StatusNoContent, nil)
+}
+
+// ReleaseFip releases the fip ip
+func (c *Conn) ReleaseFip(ip string) error {
+	return c.request("DELETE", "/api/v1/hyper/fips/"+url.PathEscape(ip), nil, http.StatusNoContent, nil)
+}
+
+// GetInfo returns the info of the region and the user, as printed by 'pi info'
+func (c *Conn) GetInfo() (map[string]string, error) {
+	info := map[string]string{}
+	err := c.request("GET", "/info", nil, http.StatusOK, &info)
+	return info, err
+}
@@ -1,6 +1,7 @@
 package hyper
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
@@ -63,10 +64,13 @@ func NewHyperCli(host string, config *rest.Config, stdin io.ReadCloser, stdout i
 		glog.V(4).Infof("NewHyperCli: replace default domain to %v", host)
 	}
 
-	tlsOptions := &tlsconfig.Options{
-		InsecureSkipVerify: true,
+	// the server certificate is verified, against the certificate authority of the
+	// cluster entry if any, unless the cluster entry sets insecure-skip-tls-verify
+	tlsConfig, err := rest.TLSConfigFor(config)
+	if err != nil {
+		return nil, err
 	}
-	httpClient, err := newHTTPClient(host, tlsOptions)
+	httpClient, err := newHTTPClient(host, tlsConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -97,15 +101,9 @@ func NewHyperCli(host string, config *rest.Config, stdin io.ReadCloser, stdout i
 	return cli, nil
 }
 
-func newHTTPClient(host string, tlsOptions *tlsconfig.Options) (*http.Client, error) {
-	if tlsOptions == nil {
-		// let the api client configure the default transport.
-		return nil, nil
-	}
-
-	config, err := tlsconfig.Client(*tlsOptions)
-	if err != nil {
-		return nil, err
+func newHTTPClient(host string, config *tls.Config) (*http.Client, error) {
+	if config == nil {
+		config = tlsconfig.ClientDefault()
 	}
 	tr := &http.Transport{
 		TLSClientConfig: config,
@@ -68,6 +68,8 @@ func (cli *HyperCli) resizeTty(ctx context.Context, id string, isExec bool) {
 	var err error
 	if isExec {
 		err = cli.Client.PodExecResize(ctx, id, options)
+	} else {
+		err = cli.Client.ContainerResize(ctx, id, options)
 	}
 
 	if err != nil {
@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var (
+	annotateLong = templates.LongDesc(i18n.T(`
+		Update the annotations on a resource(pod, job, service, secret).
+
+		All Kubernetes objects support the ability to store additional data with the object as
+		annotations. Annotations are key/value pairs that can be larger than labels and include
+		arbitrary string values such as structured JSON.
+
+		Attempting to set an annotation that already exists will fail unless --overwrite is set.
+		If --resource-version is specified and does not match the current resource version on
+		the server the command will fail.
+
+		The annotations read by Pi, such as sh_hyper_instancetype, are only taken into account
+		when the pod is created, see 'pi explain pods.metadata.annotations'.`))
+
+	annotateExample = templates.Examples(i18n.T(`
+		# Update pod 'web' with the annotation 'description' and the value 'my frontend'.
+		# If the same annotation is set multiple times, only the last value will be applied
+		pi annotate pods web description='my frontend'
+
+		# Update a pod identified by type and name in "pod.json"
+		pi annotate -f pod.json description='my frontend'
+
+		# Update pod 'web' with the annotation 'description' and the value 'my frontend running nginx', overwriting any existing value.
+		pi annotate --overwrite pods web description='my frontend running nginx'
+
+		# Update all pods in the namespace
+		pi annotate pods --all description='my frontend running nginx'
+
+		# Update pod 'web' only if the resource is unchanged from version 1.
+		pi annotate pods web description='my frontend running nginx' --resource-version=1
+
+		# Update pod 'web' by removing an annotation named 'description' if it exists.
+		# Does not require the --overwrite flag.
+		pi annotate pods web description-`))
+)
+
+// NewCmdAnnotate creates a command to update the annotations of resources
+func NewCmdAnnotate(f cmdutil.Factory, out io.Writer) *cobra.Command {
+	options := &MetadataMapOptions{
+		field:     "annotations",
+		verb:      "annotate",
+		operation: "annotated",
+		validate:  validateAnnotations,
+		Out:       out,
+	}
+	cmd := &cobra.Command{
+		Use:     "annotate [--overwrite] (-f FILENAME | TYPE NAME) KEY_1=VAL_1 ... KEY_N=VAL_N [--resource-version=version]",
+		Short:   i18n.T("Update the annotations on a resource"),
+		Long:    annotateLong,
+		Example: annotateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	addMetadataMapFlags(cmd, options, "annotation")
+	return cmd
+}
+
+func validateAnnotations(annotations map[string]string) error {
+	var errs []error
+	for key := range annotations {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, fmt.Errorf("invalid annotation key %q: %s", key, msg))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
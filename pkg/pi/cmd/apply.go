@@ -0,0 +1,389 @@
+/*
+Copyright 2014 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi"
+	"github.com/hyperhq/pi/pkg/pi/apply/parse"
+	"github.com/hyperhq/pi/pkg/pi/apply/strategy"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/cmd/util/openapi"
+	"github.com/hyperhq/pi/pkg/pi/resource"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/evanphx/json-patch"
+	"github.com/hyperhq/client-go/kubernetes/scheme"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	api "k8s.io/kubernetes/pkg/apis/core"
+)
+
+type ApplyOptions struct {
+	FilenameOptions resource.FilenameOptions
+	Selector        string
+	Prune           bool
+	All             bool
+	DryRun          bool
+	Output          string
+}
+
+var (
+	applyLong = templates.LongDesc(i18n.T(`
+		Apply a configuration to a resource(pod, job, service, secret) by filename or stdin.
+		The resource will be created if it doesn't exist yet.
+
+		The configuration of every applied object is recorded in the
+		kubectl.kubernetes.io/last-applied-configuration annotation. On the next apply the
+		recorded, local and live configurations are combined with a three-way merge, so fields
+		removed from the file are removed from the object while fields set by the server are kept.
+
+		To use 'apply' on an existing object, create it with 'pi apply' or 'pi create --save-config'.
+
+		JSON and YAML formats are accepted.`))
+
+	applyExample = templates.Examples(i18n.T(`
+		# Apply the configuration in pod.yaml to a pod.
+		pi apply -f ./pod.yaml
+
+		# Apply the JSON passed into stdin to a pod.
+		cat pod.json | pi apply -f -
+
+		# Apply every manifest in the wordpress directory, then delete the pods, services,
+		# secrets and jobs labelled app=wordpress that are no longer in those manifests.
+		pi apply --prune -f examples/wordpress -l app=wordpress`))
+)
+
+// pruneKinds are the kinds which apply --prune is allowed to delete.
+var pruneKinds = []schema.GroupVersionKind{
+	{Version: "v1", Kind: "Pod"},
+	{Version: "v1", Kind: "Service"},
+	{Version: "v1", Kind: "Secret"},
+	{Group: "batch", Version: "v1", Kind: "Job"},
+}
+
+func NewCmdApply(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
+	var options ApplyOptions
+
+	cmd := &cobra.Command{
+		Use:     "apply -f FILENAME",
+		Short:   i18n.T("Apply a configuration to a resource by filename or stdin"),
+		Long:    applyLong,
+		Example: applyExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Validate(cmd, args))
+			cmdutil.CheckErr(RunApply(f, cmd, out, errOut, &options))
+		},
+	}
+
+	usage := "that contains the configuration to apply"
+	cmdutil.AddFilenameOptionFlags(cmd, &options.FilenameOptions, usage)
+	cmd.MarkFlagRequired("filename")
+	cmdutil.AddTemplateParamFlags(cmd)
+	cmd.Flags().BoolVar(&options.Prune, "prune", false, "Automatically delete resource objects that do not appear in the configs and are created by either apply or create --save-config. Should be used with either -l or --all.")
+	cmd.Flags().StringVarP(&options.Selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().BoolVar(&options.All, "all", false, "Select all resources in the namespace of the specified resource types.")
+	cmdutil.AddDryRunFlag(cmd)
+	cmdutil.AddOutputVarFlagsForMutation(cmd, &options.Output)
+	return cmd
+}
+
+func (o *ApplyOptions) Validate(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "Unexpected args: %v", args)
+	}
+	if len(o.Selector) > 0 && o.All {
+		return cmdutil.UsageErrorf(cmd, "cannot set --all and --selector at the same time")
+	}
+	if o.Prune && len(o.Selector) == 0 && !o.All {
+		return cmdutil.UsageErrorf(cmd, "all resources selected for prune without explicitly passing --all. To prune all resources, pass the --all flag. If you did not mean to prune all resources, specify a label selector")
+	}
+	return nil
+}
+
+func RunApply(f cmdutil.Factory, cmd *cobra.Command, out, errOut io.Writer, options *ApplyOptions) error {
+	cmdNamespace, enforceNamespace, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	if options.FilenameOptions.Params, err = cmdutil.GetTemplateParams(cmd); err != nil {
+		return err
+	}
+	options.DryRun = cmdutil.GetDryRunFlag(cmd)
+
+	r := f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(cmdNamespace).DefaultNamespace().
+		FilenameParam(enforceNamespace, &options.FilenameOptions).
+		LabelSelectorParam(options.Selector).
+		Flatten().
+		Do()
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	resources, err := f.OpenAPISchema()
+	if err != nil {
+		return err
+	}
+
+	mapper := r.Mapper().RESTMapper
+	shortOutput := options.Output == "name"
+
+	visitedUids := sets.NewString()
+	visitedNamespaces := sets.NewString()
+
+	count := 0
+	err = r.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Namespaced() {
+			visitedNamespaces.Insert(info.Namespace)
+		}
+
+		// Get the modified configuration of the object. Embed the result
+		// as an annotation in the modified configuration, so that it will appear
+		// in the patch sent to the server.
+		modified, err := pi.GetModifiedConfiguration(info, true, unstructured.UnstructuredJSONScheme)
+		if err != nil {
+			return cmdutil.AddSourceToErr(fmt.Sprintf("retrieving modified configuration from:\n%v\nfor:", info), info.Source, err)
+		}
+
+		if err := info.Get(); err != nil {
+			if !errors.IsNotFound(err) {
+				return cmdutil.AddSourceToErr(fmt.Sprintf("retrieving current configuration of:\n%v\nfrom server for:", info), info.Source, err)
+			}
+
+			// Create the resource if it doesn't exist
+			// First, update the annotation used by pi apply
+			if err := pi.CreateApplyAnnotation(info, unstructured.UnstructuredJSONScheme); err != nil {
+				return cmdutil.AddSourceToErr("creating", info.Source, err)
+			}
+
+			if !options.DryRun {
+				if err := createAndRefresh(info); err != nil {
+					return cmdutil.AddSourceToErr("creating", info.Source, err)
+				}
+				if uid, err := info.Mapping.UID(info.Object); err == nil {
+					visitedUids.Insert(string(uid))
+				}
+			}
+
+			count++
+			f.PrintSuccess(mapper, shortOutput, out, info.Mapping.Resource, info.Name, options.DryRun, "created")
+			return nil
+		}
+
+		if uid, err := info.Mapping.UID(info.Object); err == nil {
+			visitedUids.Insert(string(uid))
+		}
+
+		patch, patchType, err := threeWayMergePatch(info, modified, resources)
+		if err != nil {
+			return cmdutil.AddSourceToErr(fmt.Sprintf("applying patch:\n%s\nto:\n%v\nfor:", patch, info), info.Source, err)
+		}
+
+		count++
+		if string(patch) == "{}" {
+			f.PrintSuccess(mapper, shortOutput, out, info.Mapping.Resource, info.Name, options.DryRun, "unchanged")
+			return nil
+		}
+
+		if !options.DryRun {
+			patched, err := resource.NewHelper(info.Client, info.Mapping).Patch(info.Namespace, info.Name, patchType, patch)
+			if err != nil {
+				return cmdutil.AddSourceToErr(fmt.Sprintf("applying patch:\n%s\nto:\n%v\nfor:", patch, info), info.Source, err)
+			}
+			info.Refresh(patched, true)
+		}
+
+		f.PrintSuccess(mapper, shortOutput, out, info.Mapping.Resource, info.Name, options.DryRun, "configured")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no objects passed to apply")
+	}
+
+	if !options.Prune {
+		return nil
+	}
+
+	p := pruner{
+		f:           f,
+		mapper:      mapper,
+		selector:    options.Selector,
+		visitedUids: visitedUids,
+		dryRun:      options.DryRun,
+		shortOutput: shortOutput,
+		out:         out,
+	}
+	if len(visitedNamespaces) == 0 {
+		visitedNamespaces.Insert(cmdNamespace)
+	}
+	for n := range visitedNamespaces {
+		if err := p.prune(n); err != nil {
+			return fmt.Errorf("error pruning namespaced object: %v", err)
+		}
+	}
+	return nil
+}
+
+// threeWayMergePatch merges the last-applied configuration recorded on the live
+// object, the modified local configuration and the live object itself, and returns
+// the patch that turns the live object into the merged result. Kinds described by
+// the openapi schema are merged with pkg/pi/apply; any other kind falls back to a
+// strategic merge patch computed from its versioned Go type.
+func threeWayMergePatch(info *resource.Info, modified []byte, resources openapi.Resources) ([]byte, types.PatchType, error) {
+	original, err := pi.GetOriginalConfiguration(info.Mapping, info.Object)
+	if err != nil {
+		return nil, "", err
+	}
+	current, err := runtime.Encode(unstructured.UnstructuredJSONScheme, info.Object)
+	if err != nil {
+		return nil, "", err
+	}
+
+	gvk := info.Mapping.GroupVersionKind
+	if resources == nil || resources.LookupResource(gvk) == nil {
+		versionedObject, err := scheme.Scheme.New(gvk)
+		if err != nil {
+			return nil, "", err
+		}
+		lookupPatchMeta, err := strategicpatch.NewPatchMetaFromStruct(versionedObject)
+		if err != nil {
+			return nil, "", err
+		}
+		patch, err := strategicpatch.CreateThreeWayMergePatch(original, modified, current, lookupPatchMeta, true)
+		return patch, types.StrategicMergePatchType, err
+	}
+
+	recorded := map[string]interface{}{}
+	if len(original) > 0 {
+		if err := json.Unmarshal(original, &recorded); err != nil {
+			return nil, "", err
+		}
+	}
+	local := map[string]interface{}{}
+	if err := json.Unmarshal(modified, &local); err != nil {
+		return nil, "", err
+	}
+	remote := map[string]interface{}{}
+	if err := json.Unmarshal(current, &remote); err != nil {
+		return nil, "", err
+	}
+
+	elementParser := parse.Factory{Resources: resources}
+	element, err := elementParser.CreateElement(recorded, local, remote)
+	if err != nil {
+		return nil, "", err
+	}
+	result, err := element.Merge(strategy.Create(strategy.Options{}))
+	if err != nil {
+		return nil, "", err
+	}
+
+	merged, err := json.Marshal(result.MergedResult)
+	if err != nil {
+		return nil, "", err
+	}
+	patch, err := jsonpatch.CreateMergePatch(current, merged)
+	return patch, types.MergePatchType, err
+}
+
+type pruner struct {
+	f      cmdutil.Factory
+	mapper meta.RESTMapper
+
+	selector    string
+	visitedUids sets.String
+
+	dryRun      bool
+	shortOutput bool
+	out         io.Writer
+}
+
+// prune deletes the objects in namespace that carry the last-applied annotation
+// and match the selector, but were not part of the applied configuration.
+func (p *pruner) prune(namespace string) error {
+	for _, gvk := range pruneKinds {
+		mapping, err := p.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return err
+		}
+		c, err := p.f.UnstructuredClientForMapping(mapping)
+		if err != nil {
+			return err
+		}
+		helper := resource.NewHelper(c, mapping)
+
+		objList, err := helper.List(namespace, mapping.GroupVersionKind.GroupVersion().String(), false, &metav1.ListOptions{LabelSelector: p.selector})
+		if err != nil {
+			return err
+		}
+		objs, err := meta.ExtractList(objList)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range objs {
+			annots, err := mapping.MetadataAccessor.Annotations(obj)
+			if err != nil {
+				return err
+			}
+			if _, ok := annots[api.LastAppliedConfigAnnotation]; !ok {
+				// don't prune resources not created with apply
+				continue
+			}
+			uid, err := mapping.UID(obj)
+			if err != nil {
+				return err
+			}
+			if p.visitedUids.Has(string(uid)) {
+				continue
+			}
+
+			name, err := mapping.Name(obj)
+			if err != nil {
+				return err
+			}
+			if !p.dryRun {
+				if err := helper.Delete(namespace, name); err != nil {
+					return err
+				}
+			}
+			p.f.PrintSuccess(p.mapper, p.shortOutput, p.out, mapping.Resource, name, p.dryRun, "pruned")
+		}
+	}
+	return nil
+}
@@ -17,22 +17,27 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net/url"
+	"strings"
 	"time"
 
 	restclient "github.com/hyperhq/client-go/rest"
-	"github.com/hyperhq/client-go/tools/remotecommand"
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/hypercli/pkg/promise"
+	hyperterm "github.com/hyperhq/hypercli/pkg/term"
+	"github.com/hyperhq/pi/pkg/hyper"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
 
+	dockerterm "github.com/docker/docker/pkg/term"
 	"github.com/golang/glog"
 	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/kubernetes/pkg/api/legacyscheme"
 	api "k8s.io/kubernetes/pkg/apis/core"
 	coreclient "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset/typed/core/internalversion"
+	utilexec "k8s.io/utils/exec"
 )
 
 var (
@@ -44,11 +49,20 @@ var (
 		# Switch to raw terminal mode, sends stdin to 'bash' in ruby-container from pod 123456-7890
 		# and sends stdout/stderr from 'bash' back to the client
 		pi attach 123456-7890 -c ruby-container -i -t
-		# Get output from the first pod of a ReplicaSet named nginx
-		pi attach rs/nginx
+		# Attach to ruby-container from pod 123456-7890, and detach with ctrl-x instead of ctrl-p,ctrl-q
+		pi attach 123456-7890 -c ruby-container -i -t --detach-keys=ctrl-x
 		`))
 )
 
+const (
+	// attachExitPollInterval and attachExitPolls bound how long pi attach waits
+	// for the attached container to report its exit code once the session ended.
+	attachExitPollInterval = 500 * time.Millisecond
+	attachExitPolls        = 4
+
+	defaultDetachKeys = "ctrl-p,ctrl-q"
+)
+
 func NewCmdAttach(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer) *cobra.Command {
 	options := &AttachOptions{
 		StreamOptions: StreamOptions{
@@ -56,8 +70,6 @@ func NewCmdAttach(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer)
 			Out: cmdOut,
 			Err: cmdErr,
 		},
-
-		Attach: &DefaultRemoteAttach{},
 	}
 	cmd := &cobra.Command{
 		Use: "attach (POD | TYPE/NAME) -c CONTAINER",
@@ -68,38 +80,17 @@ func NewCmdAttach(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer)
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(options.Complete(f, cmd, args))
 			cmdutil.CheckErr(options.Validate())
-			cmdutil.CheckErr(options.Run())
+			cmdutil.CheckErr(options.RunHyper())
 		},
 	}
 	cmdutil.AddPodRunningTimeoutFlag(cmd, defaultPodAttachTimeout)
+	cmd.Flags().StringVar(&options.DetachKeys, "detach-keys", defaultDetachKeys, "Key sequence for detaching from the container, in the format of a single character [a-Z] or ctrl-<value> where <value> is one of: a-z, @, ^, [, , or _")
 	cmd.Flags().StringVarP(&options.ContainerName, "container", "c", options.ContainerName, "Container name. If omitted, the first container in the pod will be chosen")
 	cmd.Flags().BoolVarP(&options.Stdin, "stdin", "i", options.Stdin, "Pass stdin to the container")
 	cmd.Flags().BoolVarP(&options.TTY, "tty", "t", options.TTY, "Stdin is a TTY")
 	return cmd
 }
 
-// RemoteAttach defines the interface accepted by the Attach command - provided for test stubbing
-type RemoteAttach interface {
-	Attach(method string, url *url.URL, config *restclient.Config, stdin io.Reader, stdout, stderr io.Writer, tty bool, terminalSizeQueue remotecommand.TerminalSizeQueue) error
-}
-
-// DefaultRemoteAttach is the standard implementation of attaching
-type DefaultRemoteAttach struct{}
-
-func (*DefaultRemoteAttach) Attach(method string, url *url.URL, config *restclient.Config, stdin io.Reader, stdout, stderr io.Writer, tty bool, terminalSizeQueue remotecommand.TerminalSizeQueue) error {
-	exec, err := remotecommand.NewSPDYExecutor(config, method, url)
-	if err != nil {
-		return err
-	}
-	return exec.Stream(remotecommand.StreamOptions{
-		Stdin:             stdin,
-		Stdout:            stdout,
-		Stderr:            stderr,
-		Tty:               tty,
-		TerminalSizeQueue: terminalSizeQueue,
-	})
-}
-
 // AttachOptions declare the arguments accepted by the Exec command
 type AttachOptions struct {
 	StreamOptions
@@ -109,7 +100,9 @@ type AttachOptions struct {
 
 	Pod *api.Pod
 
-	Attach        RemoteAttach
+	// DetachKeys is the key sequence that ends the session, leaving the container running
+	DetachKeys string
+
 	PodClient     coreclient.PodsGetter
 	GetPodTimeout time.Duration
 	Config        *restclient.Config
@@ -195,104 +188,158 @@ func (p *AttachOptions) Validate() error {
 	if p.Out == nil || p.Err == nil {
 		allErrs = append(allErrs, errors.New("both output and error output must be provided"))
 	}
-	if p.Attach == nil || p.PodClient == nil || p.Config == nil {
-		allErrs = append(allErrs, errors.New("client, client config, and attach must be provided"))
+	if p.PodClient == nil || p.Config == nil {
+		allErrs = append(allErrs, errors.New("client and client config must be provided"))
+	}
+	if len(p.DetachKeys) > 0 {
+		if _, err := hyperterm.ToBytes(p.DetachKeys); err != nil {
+			allErrs = append(allErrs, fmt.Errorf("invalid detach keys (%s): %v", p.DetachKeys, err))
+		}
 	}
 	return utilerrors.NewAggregate(allErrs)
 }
 
-// Run executes a validated remote execution against a pod.
-func (p *AttachOptions) Run() error {
-	if p.Pod == nil {
-		pod, err := p.PodClient.Pods(p.Namespace).Get(p.PodName, metav1.GetOptions{})
-		if err != nil {
-			return err
-		}
-
-		if pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
-			return fmt.Errorf("cannot attach a container in a completed pod; current phase is %s", pod.Status.Phase)
-		}
+// RunHyper attaches to a container of a pod over the Hyper hijack transport, with
+// the container attach endpoint of the Hyper API. When the container exits while
+// attached, it returns an exit error carrying the exit code of the container.
+func (p *AttachOptions) RunHyper() error {
+	pod, err := p.PodClient.Pods(p.Namespace).Get(p.PodName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
 
-		p.Pod = pod
-		// TODO: convert this to a clean "wait" behavior
+	if pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed {
+		return fmt.Errorf("cannot attach a container in a completed pod; current phase is %s", pod.Status.Phase)
 	}
-	pod := p.Pod
 
-	// check for TTY
 	containerToAttach, err := p.containerToAttachTo(pod)
 	if err != nil {
 		return fmt.Errorf("cannot attach to the container: %v", err)
 	}
 	if p.TTY && !containerToAttach.TTY {
 		p.TTY = false
-		if p.Err != nil {
-			fmt.Fprintf(p.Err, "Unable to use a TTY - container %s did not allocate one\n", containerToAttach.Name)
-		}
+		fmt.Fprintf(p.Err, "Unable to use a TTY - container %s did not allocate one\n", containerToAttach.Name)
 	} else if !p.TTY && containerToAttach.TTY {
-		// the container was launched with a TTY, so we have to force a TTY here, otherwise you'll get
-		// an error "Unrecognized input header"
+		// the container was launched with a TTY, so the output is not multiplexed
 		p.TTY = true
 	}
 
-	// ensure we can recover the terminal while attached
-	t := p.setupTTY()
+	containerID, err := attachContainerID(pod, containerToAttach.Name)
+	if err != nil {
+		return err
+	}
 
-	// save p.Err so we can print the command prompt message below
-	stderr := p.Err
+	// Set terminal emulation based on platform as required.
+	stdin, stdout, stderr := dockerterm.StdStreams()
+	cli, err := hyper.NewHyperCli(p.Config.Host, p.Config, stdin, stdout, stderr)
+	if err != nil {
+		return err
+	}
+	if err := cli.CheckTtyInput(p.Stdin, p.TTY); err != nil {
+		return err
+	}
+
+	options := types.ContainerAttachOptions{
+		Stream:     true,
+		Stdin:      p.Stdin,
+		Stdout:     true,
+		Stderr:     true,
+		DetachKeys: p.DetachKeys,
+	}
+
+	ctx := context.Background()
+	glog.V(7).Infof("ContainerAttach: pod:%v container:%v id:%v options:%v", pod.Name, containerToAttach.Name, containerID, options)
+	resp, err := cli.Client.ContainerAttach(ctx, containerID, options)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
 
-	var sizeQueue remotecommand.TerminalSizeQueue
-	if t.Raw {
-		if size := t.GetSize(); size != nil {
-			// fake resizing +1 and then back to normal so that attach-detach-reattach will result in the
-			// screen being redrawn
-			sizePlusOne := *size
-			sizePlusOne.Width++
-			sizePlusOne.Height++
+	var sIn io.ReadCloser
+	if p.Stdin {
+		sIn = cli.In
+	}
+	sErr := cli.Err
+	if p.TTY {
+		sErr = cli.Out
+	}
+	if sIn != nil && p.TTY {
+		if err := cli.SetRawTerminal(); err != nil {
+			return err
+		}
+		defer cli.RestoreTerminal(sIn)
+	}
+
+	if !p.Quiet && p.Stdin {
+		fmt.Fprintln(p.Err, "If you don't see a command prompt, try pressing enter.")
+	}
+	errCh := promise.Go(func() error {
+		return cli.HoldHijackedConnection(p.TTY, sIn, cli.Out, sErr, resp)
+	})
 
-			// this call spawns a goroutine to monitor/update the terminal size
-			sizeQueue = t.MonitorSize(&sizePlusOne, size)
+	if p.TTY && cli.IsTerminalIn {
+		if err := cli.MonitorTtySize(ctx, containerID, false); err != nil {
+			fmt.Fprintf(p.Err, "Error monitoring TTY size: %s\n", err)
 		}
+	}
 
-		// unset p.Err if it was previously set because both stdout and stderr go over p.Out when tty is
-		// true
-		p.Err = nil
+	if err := <-errCh; err != nil {
+		glog.Errorf("Error hijack: %s", err)
+		return err
 	}
 
-	fn := func() error {
-		restClient, err := restclient.RESTClientFor(p.Config)
+	return p.containerExitStatus(pod.Name, containerToAttach.Name)
+}
+
+// containerExitStatus waits for the attached container to terminate, and returns
+// an exit error when it exited with a non zero code. A container still
+// running when the session ended was detached from, which is not an error.
+func (p *AttachOptions) containerExitStatus(podName, containerName string) error {
+	for i := 0; i < attachExitPolls; i++ {
+		pod, err := p.PodClient.Pods(p.Namespace).Get(podName, metav1.GetOptions{})
 		if err != nil {
 			return err
 		}
-		// TODO: consider abstracting into a client invocation or client helper
-		req := restClient.Post().
-			Resource("pods").
-			Name(pod.Name).
-			Namespace(pod.Namespace).
-			SubResource("attach")
-		req.VersionedParams(&api.PodAttachOptions{
-			Container: containerToAttach.Name,
-			Stdin:     p.Stdin,
-			Stdout:    p.Out != nil,
-			Stderr:    p.Err != nil,
-			TTY:       t.Raw,
-		}, legacyscheme.ParameterCodec)
-
-		return p.Attach.Attach("POST", req.URL(), p.Config, p.In, p.Out, p.Err, t.Raw, sizeQueue)
-	}
-
-	if !p.Quiet && stderr != nil {
-		fmt.Fprintln(stderr, "If you don't see a command prompt, try pressing enter.")
-	}
-	if err := t.Safe(fn); err != nil {
-		return err
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Name != containerName || status.State.Terminated == nil {
+				continue
+			}
+			if code := status.State.Terminated.ExitCode; code != 0 {
+				return utilexec.CodeExitError{
+					Err:  fmt.Errorf("container %s exited with code %d", containerName, code),
+					Code: int(code),
+				}
+			}
+			return nil
+		}
+		time.Sleep(attachExitPollInterval)
 	}
 
-	if p.Stdin && t.Raw && pod.Spec.RestartPolicy == api.RestartPolicyAlways {
-		fmt.Fprintf(p.Out, "Session ended, resume using '%s %s -c %s -i -t' command when the pod is running\n", p.CommandName, pod.Name, containerToAttach.Name)
+	if p.Stdin && p.TTY {
+		fmt.Fprintf(p.Err, "Session ended, resume using '%s %s -c %s -i -t' command when the pod is running\n", p.CommandName, podName, containerName)
 	}
 	return nil
 }
 
+// attachContainerID returns the id of the running container containerName of pod,
+// which the container endpoints of the Hyper API take
+func attachContainerID(pod *api.Pod, containerName string) (string, error) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+		id := status.ContainerID
+		if i := strings.Index(id, "://"); i >= 0 {
+			id = id[i+len("://"):]
+		}
+		if len(id) == 0 || status.State.Running == nil {
+			return "", fmt.Errorf("container %s of pod %s is not running", containerName, pod.Name)
+		}
+		return id, nil
+	}
+	return "", fmt.Errorf("container %s of pod %s is not running", containerName, pod.Name)
+}
+
 // containerToAttach returns a reference to the container to attach to, given
 // by name or the first container if name is empty.
 func (p *AttachOptions) containerToAttachTo(pod *api.Pod) (*api.Container, error) {
@@ -225,7 +225,13 @@ func NewPiCommand(f cmdutil.Factory, in io.Reader, out, err io.Writer) *cobra.Co
 				resource.NewCmdGet(f, out, err),
 				NewCmdDelete(f, out, err),
 				NewCmdRun(f, in, out, err),
+				NewCmdScale(f, out),
+				NewCmdRollout(f, out, err),
 				NewCmdName(f, out, err),
+				NewCmdResize(f, out, err),
+				NewCmdMove(f, out, err),
+				NewCmdExplain(f, out, err),
+				NewCmdEdit(f, out, err),
 			},
 		},
 		{
@@ -234,6 +240,27 @@ func NewPiCommand(f cmdutil.Factory, in io.Reader, out, err io.Writer) *cobra.Co
 				NewCmdDescribe(f, out, err),
 				NewCmdLogs(f, out),
 				NewCmdExec(f, in, out, err),
+				NewCmdAttach(f, in, out, err),
+				NewCmdCp(f, out, err),
+				NewCmdPortForward(f, out, err),
+				NewCmdDebug(f, in, out, err),
+				NewCmdEvents(f, out, err),
+				NewCmdTop(f, out, err),
+			},
+		},
+		{
+			Message: "Settings Commands:",
+			Commands: []*cobra.Command{
+				NewCmdLabel(f, out),
+				NewCmdAnnotate(f, out),
+			},
+		},
+		{
+			Message: "Advanced Commands:",
+			Commands: []*cobra.Command{
+				NewCmdApply(f, out, err),
+				NewCmdWait(f, out, err),
+				NewCmdLint(f, out),
 			},
 		},
 	}
@@ -47,6 +47,9 @@ func NewCmdConfig(pathOptions *clientcmd.PathOptions, out, errOut io.Writer) *co
 			# Set credential for user with region
 			pi config set-credentials user1 --region=gcp-us-central1 --access-key="xxx" --secret-key="xxxxxx"
 
+			# Encrypt the credentials of all users with a passphrase
+			pi config migrate-credentials --credential-store=file
+
 			# Print credentials for current user
 			pi config view --minify=true
 
@@ -62,8 +65,9 @@ func NewCmdConfig(pathOptions *clientcmd.PathOptions, out, errOut io.Writer) *co
 	cmd.PersistentFlags().StringVar(&pathOptions.LoadingRules.ExplicitPath, pathOptions.ExplicitFileFlag, pathOptions.LoadingRules.ExplicitPath, "use a particular pi config file")
 
 	cmd.AddCommand(NewCmdConfigView(out, errOut, pathOptions))
-	//cmd.AddCommand(NewCmdConfigSetCluster(out, pathOptions))
+	cmd.AddCommand(NewCmdConfigSetCluster(out, pathOptions))
 	cmd.AddCommand(NewCmdConfigSetAuthInfo(out, pathOptions))
+	cmd.AddCommand(NewCmdConfigMigrateCredentials(out, pathOptions))
 	cmd.AddCommand(NewCmdConfigSetContext(out, pathOptions))
 	//cmd.AddCommand(NewCmdConfigSet(out, pathOptions))
 	//cmd.AddCommand(NewCmdConfigUnset(out, pathOptions))
@@ -28,6 +28,7 @@ import (
 	clientcmdapi "github.com/hyperhq/client-go/tools/clientcmd/api"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/credentials"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
 
 	"github.com/golang/glog"
@@ -50,9 +51,10 @@ type createAuthInfoOptions struct {
 	authProviderArgs         map[string]string
 	authProviderArgsToRemove []string
 
-	region    flag.StringFlag
-	accessKey flag.StringFlag
-	secretKey flag.StringFlag
+	region          flag.StringFlag
+	accessKey       flag.StringFlag
+	secretKey       flag.StringFlag
+	credentialStore flag.StringFlag
 }
 
 var (
@@ -67,11 +69,24 @@ var (
 			Credentials flags:
 			  --%v=access_key --%v=secret_key
 
-		Bearer token and basic auth are mutually exclusive.`), clientcmd.FlagRegion, clientcmd.FlagAccessKey, clientcmd.FlagSecretKey)
+			Credential store flags:
+			  --%v=plaintext|file|helper_name
+
+		The keys are kept in plaintext in pi config, unless a credential store is given:
+		"file" encrypts them with a passphrase in ~/.pi/credentials, any other name runs
+		the credential helper pi-credential-NAME.
+
+		Bearer token and basic auth are mutually exclusive.`), clientcmd.FlagRegion, clientcmd.FlagAccessKey, clientcmd.FlagSecretKey, flagCredentialStore)
 
 	create_authinfo_example = templates.Examples(`
 		# Set credentials for hyper user
-		pi config set-credentials user1 --region=gcp-us-central1 --access-key=xxxx --secret-key=xxxxxxxxx`)
+		pi config set-credentials user1 --region=gcp-us-central1 --access-key=xxxx --secret-key=xxxxxxxxx
+
+		# Set credentials for hyper user, encrypted with a passphrase
+		pi config set-credentials user1 --access-key=xxxx --secret-key=xxxxxxxxx --credential-store=file
+
+		# Set credentials for hyper user, kept by the credential helper pi-credential-osxkeychain
+		pi config set-credentials user1 --access-key=xxxx --secret-key=xxxxxxxxx --credential-store=osxkeychain`)
 )
 
 func NewCmdConfigSetAuthInfo(out io.Writer, configAccess clientcmd.ConfigAccess) *cobra.Command {
@@ -99,6 +114,7 @@ func newCmdConfigSetAuthInfo(out io.Writer, options *createAuthInfoOptions) *cob
 	cmd.Flags().Var(&options.accessKey, clientcmd.FlagAccessKey, clientcmd.FlagAccessKey+" for the user entry in pi config")
 	cmd.Flags().Var(&options.secretKey, clientcmd.FlagSecretKey, clientcmd.FlagSecretKey+" for the user entry in pi config")
 	cmd.Flags().Var(&options.region, clientcmd.FlagRegion, "region for the user entry in pi config")
+	cmd.Flags().Var(&options.credentialStore, flagCredentialStore, "store keeping the access and secret keys: plaintext, file or the name of a credential helper")
 
 	return cmd
 }
@@ -136,6 +152,9 @@ func (o createAuthInfoOptions) run() error {
 		startingStanzaAuth = clientcmdapi.NewAuthInfo()
 	}
 	authInfo := o.modifyAuthInfo(*startingStanzaAuth)
+	if err := o.storeCredential(config, &authInfo); err != nil {
+		return err
+	}
 	config.AuthInfos[o.name] = &authInfo
 
 	if config.CurrentContext == "" {
@@ -157,8 +176,6 @@ func (o *createAuthInfoOptions) modifyDefaultCluster(existingCluster clientcmdap
 		modifiedCluster.Server = clientcmd.DefaultServer
 	}
 
-	modifiedCluster.InsecureSkipTLSVerify = true
-
 	return modifiedCluster
 }
 
@@ -282,6 +299,33 @@ func (o *createAuthInfoOptions) modifyAuthInfo(existingAuthInfo clientcmdapi.Aut
 	return modifiedAuthInfo
 }
 
+// storeCredential moves the keys of authInfo into the credential store it references
+func (o *createAuthInfoOptions) storeCredential(config *clientcmdapi.Config, authInfo *clientcmdapi.AuthInfo) error {
+	if o.credentialStore.Provided() {
+		storeName := credentials.StoreName(authInfo)
+		if storeName != "" && storeName != credentials.StoreReference(o.credentialStore.Value()) && !o.accessKey.Provided() && !o.secretKey.Provided() {
+			return fmt.Errorf("the keys of user %q are kept in the %s credential store, use 'pi config migrate-credentials' to move them", o.name, storeName)
+		}
+		credentials.SetStoreName(authInfo, o.credentialStore.Value())
+	}
+	storeName := credentials.StoreName(authInfo)
+	if storeName == "" || (authInfo.AccessKey == "" && authInfo.SecretKey == "") {
+		return nil
+	}
+
+	store, err := credentials.NewStore(storeName, config)
+	if err != nil {
+		return err
+	}
+	credential := credentials.Credential{AccessKey: authInfo.AccessKey, SecretKey: authInfo.SecretKey}
+	if err := store.Store(o.name, credential); err != nil {
+		return err
+	}
+	authInfo.AccessKey = ""
+	authInfo.SecretKey = ""
+	return nil
+}
+
 func (o *createAuthInfoOptions) complete(cmd *cobra.Command, out io.Writer) error {
 	args := cmd.Flags().Args()
 	if len(args) != 1 {
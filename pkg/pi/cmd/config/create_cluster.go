@@ -46,17 +46,24 @@ var (
 	create_cluster_long = templates.LongDesc(`
 		Sets a cluster entry in pi config.
 
-		Specifying a name that already exists will merge new fields on top of existing values for those fields.`)
+		Specifying a name that already exists will merge new fields on top of existing values for those fields.
+
+		The certificate of the server is verified against the system root certificates, or the
+		certificate authority of the cluster entry. Verification can be disabled per cluster entry
+		with --insecure-skip-tls-verify.`)
 
 	create_cluster_example = templates.Examples(`
-		# Set only the server field on the e2e cluster entry without touching other values.
-		pi config set-cluster e2e --server=https://1.2.3.4
+		# Set only the server field on the default cluster entry without touching other values.
+		pi config set-cluster default --server=https://*.hyper.sh:443
 
-		# Embed certificate authority data for the e2e cluster entry
-		pi config set-cluster e2e --certificate-authority=~/.pi/e2e/kubernetes.ca.crt
+		# Embed certificate authority data for the default cluster entry
+		pi config set-cluster default --certificate-authority=~/.pi/ca.crt --embed-certs=true
 
 		# Disable cert checking for the dev cluster entry
-		pi config set-cluster e2e --insecure-skip-tls-verify=true`)
+		pi config set-cluster dev --insecure-skip-tls-verify=true
+
+		# Enable cert checking again for the default cluster entry
+		pi config set-cluster default --insecure-skip-tls-verify=false`)
 )
 
 func NewCmdConfigSetCluster(out io.Writer, configAccess clientcmd.ConfigAccess) *cobra.Command {
@@ -23,6 +23,7 @@ import (
 	"github.com/hyperhq/client-go/tools/clientcmd"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/credentials"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
 	"github.com/spf13/cobra"
 )
@@ -66,11 +67,21 @@ func runDeleteAuthInfo(out, errOut io.Writer, configAccess clientcmd.ConfigAcces
 	}
 
 	name := args[0]
-	_, exists := config.AuthInfos[name]
+	authInfo, exists := config.AuthInfos[name]
 	if !exists {
 		return fmt.Errorf("credentials %v not found", name)
 	}
 
+	if storeName := credentials.StoreName(authInfo); storeName != "" {
+		store, err := credentials.NewStore(storeName, config)
+		if err == nil {
+			err = store.Erase(name)
+		}
+		if err != nil {
+			fmt.Fprintf(errOut, "warning: the keys of %s were not erased from the %s credential store: %v\n", name, storeName, err)
+		}
+	}
+
 	delete(config.AuthInfos, name)
 
 	if err := clientcmd.ModifyConfig(configAccess, *config, true); err != nil {
@@ -0,0 +1,155 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/hyperhq/client-go/tools/clientcmd"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/credentials"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+const flagCredentialStore = "credential-store"
+
+type migrateCredentialsOptions struct {
+	configAccess    clientcmd.ConfigAccess
+	names           []string
+	credentialStore string
+}
+
+var (
+	migrate_credentials_long = templates.LongDesc(`
+		Moves the access and secret keys of users into a credential store.
+
+		The store is one of: plaintext, the keys are kept in pi config; file, the keys are
+		encrypted with a passphrase in ~/.pi/credentials; or the name of a credential helper,
+		the keys are kept by the executable pi-credential-NAME, which speaks the git credential
+		helper protocol.
+
+		The passphrase of the file store is read from PI_CREDENTIAL_PASSPHRASE, or asked on the terminal.`)
+
+	migrate_credentials_example = templates.Examples(`
+		# Encrypt the keys of all users with a passphrase
+		pi config migrate-credentials --credential-store=file
+
+		# Move the keys of user1 into the OS keychain, with the git credential helper
+		ln -s $(which git-credential-osxkeychain) /usr/local/bin/pi-credential-osxkeychain
+		pi config migrate-credentials user1 --credential-store=osxkeychain
+
+		# Move the keys of user1 back into pi config
+		pi config migrate-credentials user1 --credential-store=plaintext`)
+)
+
+func NewCmdConfigMigrateCredentials(out io.Writer, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &migrateCredentialsOptions{configAccess: configAccess}
+
+	cmd := &cobra.Command{
+		Use:     "migrate-credentials [NAME...] --credential-store=plaintext|file|helper_name",
+		Short:   i18n.T("Moves the keys of users into a credential store"),
+		Long:    migrate_credentials_long,
+		Example: migrate_credentials_example,
+		Run: func(cmd *cobra.Command, args []string) {
+			options.names = args
+			cmdutil.CheckErr(options.run(out))
+		},
+	}
+	cmd.Flags().StringVar(&options.credentialStore, flagCredentialStore, options.credentialStore, "store to move the keys into: plaintext, file or the name of a credential helper")
+	cmd.MarkFlagRequired(flagCredentialStore)
+	return cmd
+}
+
+func (o *migrateCredentialsOptions) run(out io.Writer) error {
+	if o.credentialStore == "" {
+		return fmt.Errorf("--%s is required", flagCredentialStore)
+	}
+
+	config, err := o.configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	names := o.names
+	if len(names) == 0 {
+		for name := range config.AuthInfos {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+
+	target, err := credentials.NewStore(o.credentialStore, config)
+	if err != nil {
+		return err
+	}
+	reference := credentials.StoreReference(target.Name())
+
+	migrated := []string{}
+	for _, name := range names {
+		authInfo, found := config.AuthInfos[name]
+		if !found {
+			return fmt.Errorf("credentials %v not found", name)
+		}
+		storeName := credentials.StoreName(authInfo)
+		if storeName == reference {
+			continue
+		}
+		if storeName == "" && authInfo.AccessKey == "" && authInfo.SecretKey == "" {
+			continue
+		}
+
+		source, err := credentials.NewStore(storeName, config)
+		if err != nil {
+			return err
+		}
+		credential, err := source.Get(name)
+		if err != nil {
+			return err
+		}
+		if err := target.Store(name, credential); err != nil {
+			return err
+		}
+		credentials.SetStoreName(authInfo, target.Name())
+		// the keys are erased from an external source store once the config is saved,
+		// so they are never lost if the migration is interrupted
+		if source.Name() == credentials.PlaintextStore {
+			source.Erase(name)
+		}
+		if err := clientcmd.ModifyConfig(o.configAccess, *config, true); err != nil {
+			return err
+		}
+		if source.Name() != credentials.PlaintextStore {
+			if err := source.Erase(name); err != nil {
+				return err
+			}
+		}
+		migrated = append(migrated, name)
+	}
+
+	for _, name := range migrated {
+		fmt.Fprintf(out, "User %q migrated to the %s credential store.\n", name, target.Name())
+	}
+	if len(migrated) == 0 {
+		fmt.Fprintf(out, "No credentials to migrate to the %s credential store.\n", target.Name())
+	}
+	return nil
+}
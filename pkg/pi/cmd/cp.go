@@ -0,0 +1,279 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	restclient "github.com/hyperhq/client-go/rest"
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/archive"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/kubernetes/pkg/apis/core"
+	coreclient "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset/typed/core/internalversion"
+)
+
+var (
+	cpExample = templates.Examples(i18n.T(`
+		# Copy /tmp/foo_dir local directory to /tmp/bar_dir in a remote pod
+		pi cp /tmp/foo_dir some-pod:/tmp/bar_dir
+
+		# Copy a local file into the /tmp directory of the pod mysql
+		pi cp ./dump.sql mysql:/tmp/
+
+		# Copy /tmp/foo local file to /tmp/bar in a remote pod in a specific container
+		pi cp /tmp/foo some-pod:/tmp/bar -c specific-container
+
+		# Copy /var/log from the nginx container of the pod web to the local directory ./logs
+		pi cp web:/var/log ./logs -c nginx`))
+
+	cpUsageStr = "expected 'cp <file-spec-src> <file-spec-dest> [-c container]'.\n" +
+		"<file-spec> is [pod]:<path> for a path in a pod, or <path> for a path in the local filesystem"
+)
+
+// CopyOptions declare the arguments accepted by the Copy command
+type CopyOptions struct {
+	Namespace string
+	Container string
+
+	PodClient coreclient.PodsGetter
+	Config    *restclient.Config
+
+	Out io.Writer
+	Err io.Writer
+}
+
+// NewCmdCp creates a command to copy files and directories to and from containers
+func NewCmdCp(f cmdutil.Factory, cmdOut, cmdErr io.Writer) *cobra.Command {
+	options := &CopyOptions{
+		Out: cmdOut,
+		Err: cmdErr,
+	}
+	cmd := &cobra.Command{
+		Use:     "cp <file-spec-src> <file-spec-dest>",
+		Short:   i18n.T("Copy files and directories to and from containers."),
+		Long:    "Copy files and directories to and from containers. File modes are preserved.",
+		Example: cpExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run(args))
+		},
+	}
+	cmd.Flags().StringVarP(&options.Container, "container", "c", "", "Container name. If omitted, the first container in the pod will be chosen")
+	return cmd
+}
+
+type fileSpec struct {
+	PodName string
+	File    string
+}
+
+var (
+	errFileSpecDoesntMatchFormat = errors.New("Filespec must match the canonical format: [pod]:<path>")
+	errFileCannotBeEmpty         = errors.New("Filepath can not be empty")
+)
+
+func extractFileSpec(arg string) (fileSpec, error) {
+	pieces := strings.Split(arg, ":")
+	if len(pieces) == 1 {
+		return fileSpec{File: arg}, nil
+	}
+	if len(pieces) != 2 || len(pieces[0]) == 0 {
+		// FIXME Kubernetes can't copy files that contain a ':'
+		// character.
+		return fileSpec{}, errFileSpecDoesntMatchFormat
+	}
+	if len(pieces[1]) == 0 {
+		return fileSpec{}, errFileCannotBeEmpty
+	}
+	return fileSpec{PodName: pieces[0], File: pieces[1]}, nil
+}
+
+// Complete completes all the required options for cp.
+func (o *CopyOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmdutil.UsageErrorf(cmd, cpUsageStr)
+	}
+
+	namespace, _, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	config, err := f.ClientConfig()
+	if err != nil {
+		return err
+	}
+	o.Config = config
+
+	clientset, err := f.ClientSet()
+	if err != nil {
+		return err
+	}
+	o.PodClient = clientset.Core()
+	return nil
+}
+
+// Run copies the source file spec to the destination file spec.
+func (o *CopyOptions) Run(args []string) error {
+	srcSpec, err := extractFileSpec(args[0])
+	if err != nil {
+		return err
+	}
+	destSpec, err := extractFileSpec(args[1])
+	if err != nil {
+		return err
+	}
+	if len(srcSpec.PodName) != 0 && len(destSpec.PodName) != 0 {
+		return fmt.Errorf("one of src or dest must be a local file specification")
+	}
+	if len(srcSpec.PodName) != 0 {
+		return o.copyFromPod(srcSpec, destSpec)
+	}
+	if len(destSpec.PodName) != 0 {
+		return o.copyToPod(srcSpec, destSpec)
+	}
+	return fmt.Errorf("one of src or dest must be a remote file specification")
+}
+
+// containerID returns the id of the container to copy from or to, the archive
+// endpoints address containers by id rather than by pod and container name.
+func (o *CopyOptions) containerID(podName string) (string, error) {
+	pod, err := o.PodClient.Pods(o.Namespace).Get(podName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	if pod.Status.Phase != api.PodRunning {
+		return "", fmt.Errorf("cannot copy files of a pod that is not running; current phase is %s", pod.Status.Phase)
+	}
+
+	containerName := o.Container
+	if len(containerName) == 0 {
+		if len(pod.Spec.Containers) > 1 {
+			fmt.Fprintf(o.Err, "Defaulting container name to %s.\n", pod.Spec.Containers[0].Name)
+		}
+		containerName = pod.Spec.Containers[0].Name
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name != containerName {
+			continue
+		}
+		id := status.ContainerID
+		if i := strings.Index(id, "://"); i >= 0 {
+			id = id[i+len("://"):]
+		}
+		if len(id) == 0 {
+			return "", fmt.Errorf("container %s of pod %s is not running", containerName, podName)
+		}
+		return id, nil
+	}
+	return "", fmt.Errorf("container %s is not valid for pod %s", containerName, podName)
+}
+
+func (o *CopyOptions) copyToPod(src, dest fileSpec) error {
+	if _, err := os.Lstat(src.File); err != nil {
+		return fmt.Errorf("%s doesn't exist in local filesystem", src.File)
+	}
+	id, err := o.containerID(dest.PodName)
+	if err != nil {
+		return err
+	}
+	cli, err := hyper.NewHyperCli(o.Config.Host, o.Config, nil, o.Out, o.Err)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	// Like 'cp', copying into an existing directory keeps the name of the
+	// source, any other destination is the new name of the source.
+	destDir, name := dest.File, filepath.Base(src.File)
+	stat, err := cli.Client.ContainerStatPath(ctx, id, dest.File)
+	if err != nil && !isPathNotFound(err) {
+		return err
+	}
+	if err != nil || !stat.Mode.IsDir() {
+		if strings.HasSuffix(dest.File, "/") {
+			return fmt.Errorf("%s is not a directory in pod %s", dest.File, dest.PodName)
+		}
+		destDir, name = path.Dir(dest.File), path.Base(dest.File)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(archive.MakeTar(src.File, name, writer))
+	}()
+	err = cli.Client.CopyToContainer(ctx, id, destDir, reader, types.CopyToContainerOptions{})
+	reader.Close()
+	return err
+}
+
+func (o *CopyOptions) copyFromPod(src, dest fileSpec) error {
+	id, err := o.containerID(src.PodName)
+	if err != nil {
+		return err
+	}
+	cli, err := hyper.NewHyperCli(o.Config.Host, o.Config, nil, o.Out, o.Err)
+	if err != nil {
+		return err
+	}
+
+	content, stat, err := cli.Client.CopyFromContainer(context.Background(), id, src.File)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	// The archive holds a single top level entry named after the source. It
+	// keeps its name when copied into an existing directory, and is renamed
+	// to the destination otherwise.
+	destDir, rename := dest.File, ""
+	info, err := os.Stat(dest.File)
+	switch {
+	case err == nil && info.IsDir():
+	case err == nil && stat.Mode.IsDir():
+		return fmt.Errorf("cannot copy a directory to the file %s", dest.File)
+	case err != nil && !os.IsNotExist(err):
+		return err
+	case err != nil && strings.HasSuffix(dest.File, string(filepath.Separator)):
+		return fmt.Errorf("destination directory %s does not exist", dest.File)
+	default:
+		destDir, rename = filepath.Dir(dest.File), filepath.Base(dest.File)
+	}
+	return archive.UntarAll(content, destDir, stat.Name, rename, o.Err)
+}
+
+// isPathNotFound returns true if err is the error of ContainerStatPath for a path
+// which does not exist. The response of the HEAD request has no body, the error
+// only names the status.
+func isPathNotFound(err error) bool {
+	return strings.Contains(err.Error(), "returned "+http.StatusText(http.StatusNotFound))
+}
@@ -17,22 +17,27 @@ limitations under the License.
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	//"net/url"
 	"os"
 	//"strings"
 
-	"github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	hyperclient "github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/pi/pkg/hyper"
 	"github.com/hyperhq/pi/pkg/pi"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
 	"github.com/hyperhq/pi/pkg/pi/resource"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
 
+	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
 type CreateOptions struct {
@@ -46,7 +51,9 @@ var (
 	createLong = templates.LongDesc(i18n.T(`
 		Create a resource(pod, job, service, secret, volume, fip).
 
-		JSON and YAML formats are accepted(pod, job, service, secret).`))
+		JSON and YAML formats are accepted(pod, job, service, secret, and the pseudo-kinds Volume and FloatingIP).
+		The volumes and fips of the files are created first, a LoadBalancer service may set its loadBalancerIP
+		to the name of a fip.`))
 
 	createExample = templates.Examples(i18n.T(`
 		# Create a pod using the data in yaml.
@@ -62,7 +69,13 @@ var (
 		pi create -f examples/service/service-nginx.yaml
 
 		# Create a secret using the data in yaml.
-		pi create -f examples/secret/secret-dockerconfigjson.yaml`))
+		pi create -f examples/secret/secret-dockerconfigjson.yaml
+
+		# Create the volumes, fips, pods, services and secrets of a stack.
+		pi create -f examples/wordpress/stack.tpl.yaml --param MYSQL_ROOT_PASSWORD=abcd1234
+
+		# Create a service from a template, replacing $FIP and the references to the params of prod.env.
+		pi create -f wordpress-service.tpl.yaml --param FIP=1.2.3.4 --param-file=prod.env`))
 )
 
 func NewCmdCreate(f cmdutil.Factory, cmdIn io.Reader, out, errOut io.Writer) *cobra.Command {
@@ -87,14 +100,15 @@ func NewCmdCreate(f cmdutil.Factory, cmdIn io.Reader, out, errOut io.Writer) *co
 	usage := "to use to create the resource"
 	cmdutil.AddFilenameOptionFlags(cmd, &options.FilenameOptions, usage)
 	cmd.MarkFlagRequired("filename")
+	cmdutil.AddTemplateParamFlags(cmd)
 	//cmdutil.AddValidateFlags(cmd)
-	//cmdutil.AddPrinterFlags(cmd)
+	cmdutil.AddPrinterFlags(cmd)
 	//cmd.Flags().BoolVar(&options.EditBeforeCreate, "edit", false, "Edit the API resource before creating")
 	//cmd.Flags().Bool("windows-line-endings", runtime.GOOS == "windows",
 	//	"Only relevant if --edit=true. Defaults to the line ending native to your platform.")
-	//cmdutil.AddApplyAnnotationFlags(cmd)
+	cmdutil.AddApplyAnnotationFlags(cmd)
 	//cmdutil.AddRecordFlag(cmd)
-	//cmdutil.AddDryRunFlag(cmd)
+	cmdutil.AddDryRunFlag(cmd)
 	//cmd.Flags().StringVarP(&options.Selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
 	//cmd.Flags().StringVar(&options.Raw, "raw", options.Raw, "Raw URI to POST to the server.  Uses the transport specified by the kubeconfig file.")
 
@@ -106,8 +120,11 @@ func NewCmdCreate(f cmdutil.Factory, cmdIn io.Reader, out, errOut io.Writer) *co
 	// create volume, fip
 	cmd.AddCommand(NewCmdCreateVolume(f, out, errOut))
 	cmd.AddCommand(NewCmdCreateFip(f, out, errOut))
+	cmd.AddCommand(NewCmdCreateSnapshot(f, out, errOut))
+	cmd.AddCommand(NewCmdCreateSecurityGroup(f, out, errOut))
 
 	cmd.AddCommand(NewCmdCreateJob(f, out, errOut))
+	cmd.AddCommand(NewCmdCreateCronJob(f, out, errOut))
 	return cmd
 }
 
@@ -179,9 +196,13 @@ func RunCreate(f cmdutil.Factory, cmd *cobra.Command, out, errOut io.Writer, opt
 	if err != nil {
 		return err
 	}
+	if options.FilenameOptions.Params, err = cmdutil.GetTemplateParams(cmd); err != nil {
+		return err
+	}
 
 	r := f.NewBuilder().
 		Unstructured().
+		HyperObjects().
 		//Schema(schema).
 		ContinueOnError().
 		NamespaceParam(cmdNamespace).DefaultNamespace().
@@ -194,19 +215,43 @@ func RunCreate(f cmdutil.Factory, cmd *cobra.Command, out, errOut io.Writer, opt
 		return err
 	}
 
-	//dryRun := cmdutil.GetFlagBool(cmd, "dry-run")
-	//output := cmdutil.GetFlagString(cmd, "output")
-	dryRun := false
-	output := "name"
+	dryRun := cmdutil.GetDryRunFlag(cmd)
+	output := cmdutil.GetFlagString(cmd, "output")
+	if len(output) == 0 && !dryRun {
+		output = "name"
+	}
 
 	mapper := r.Mapper().RESTMapper
 
+	// the documents which could not be loaded are reported with the errors of the
+	// others, the volumes and fips are created before the pods and services using them
+	infos, loadErr := r.Infos()
+	resource.SortByDependency(infos)
+
 	count := 0
-	err = r.Visit(func(info *resource.Info, err error) error {
+	err = resource.ContinueOnErrorVisitor{Visitor: resource.InfoListVisitor(infos)}.Visit(func(info *resource.Info, err error) error {
 		if err != nil {
 			return err
 		}
 
+		if resource.IsHyperObject(info) {
+			if !dryRun {
+				if err := createHyperObject(f, info); err != nil {
+					return cmdutil.AddSourceToErr("creating", info.Source, err)
+				}
+			}
+			count++
+			if len(output) > 0 && output != "name" {
+				return f.PrintResourceInfoForCommand(cmd, info, out)
+			}
+			printHyperSuccess(out, output == "name", info, dryRun, "created")
+			return nil
+		}
+
+		if err := pi.CreateOrUpdateAnnotation(cmdutil.GetFlagBool(cmd, cmdutil.ApplyAnnotationsFlag), info, unstructured.UnstructuredJSONScheme); err != nil {
+			return cmdutil.AddSourceToErr("creating", info.Source, err)
+		}
+
 		//if cmdutil.ShouldRecord(cmd, info) {
 		//	if err := cmdutil.RecordChangeCause(info.Object, f.Command(cmd, false)); err != nil {
 		//		return cmdutil.AddSourceToErr("creating", info.Source, err)
@@ -214,6 +259,9 @@ func RunCreate(f cmdutil.Factory, cmd *cobra.Command, out, errOut io.Writer, opt
 		//}
 
 		if !dryRun {
+			if err := resolveLoadBalancerFip(f, info); err != nil {
+				return cmdutil.AddSourceToErr("creating", info.Source, err)
+			}
 			if err := createAndRefresh(info); err != nil {
 				return cmdutil.AddSourceToErr("creating", info.Source, err)
 			}
@@ -232,7 +280,7 @@ func RunCreate(f cmdutil.Factory, cmd *cobra.Command, out, errOut io.Writer, opt
 		f.PrintSuccess(mapper, shortOutput, out, info.Mapping.Resource, info.Name, dryRun, "created")
 		return nil
 	})
-	if err != nil {
+	if err := utilerrors.Reduce(utilerrors.Flatten(utilerrors.NewAggregate([]error{loadErr, err}))); err != nil {
 		return err
 	}
 	if count == 0 {
@@ -302,8 +350,8 @@ func RunCreateSubcommand(f cmdutil.Factory, cmd *cobra.Command, out io.Writer, o
 	}
 	obj = info.Object
 
-	dryRun := false
-	outputFormat := "name"
+	dryRun := cmdutil.GetDryRunFlag(cmd)
+	outputFormat := cmdutil.GetFlagString(cmd, "output")
 
 	if !dryRun {
 		obj, err = resource.NewHelper(client, mapping).Create(namespace, false, info.Object)
@@ -316,7 +364,7 @@ func RunCreateSubcommand(f cmdutil.Factory, cmd *cobra.Command, out io.Writer, o
 		}
 	}
 
-	if useShortOutput := outputFormat == "name"; useShortOutput || len(outputFormat) == 0 {
+	if useShortOutput := outputFormat == "name" || (len(outputFormat) == 0 && !dryRun); useShortOutput || len(outputFormat) == 0 {
 		f.PrintSuccess(mapper, useShortOutput, out, mapping.Resource, info.Name, dryRun, "created")
 		return nil
 	}
@@ -331,21 +379,37 @@ func RunCreateVolumeSubcommand(f cmdutil.Factory, cmd *cobra.Command, out io.Wri
 	if err != nil {
 		return err
 	}
-	opts := obj.(*hyper.VolumeCreateRequest)
-	if opts.Size < 1 {
+	opts := obj.(*pi.VolumeCreateRequest)
+	if opts.Snapshot == "" && opts.Size < 1 {
 		return fmt.Errorf("volume size should be >=1 (GB)")
 	}
-	if cfg, err := f.ClientConfig(); err != nil {
-		return err
-	} else {
-		hyperConn := hyper.NewHyperConn(cfg)
-		volCli := hyper.NewVolumeCli(hyperConn)
-		if _, volCreated, err := volCli.CreateVolume(opts.Name, opts.Zone, fmt.Sprintf("%v", opts.Size)); err != nil {
-			return err
+
+	dryRun := cmdutil.GetDryRunFlag(cmd)
+	outputFormat := cmdutil.GetFlagString(cmd, "output")
+	if dryRun {
+		if len(outputFormat) > 0 && outputFormat != "name" {
+			return printHyperRequest(out, outputFormat, opts)
+		}
+		if outputFormat == "name" {
+			fmt.Fprintf(out, "volume/%v\n", opts.Name)
 		} else {
-			fmt.Printf("volume/%v\n", volCreated.Name)
+			fmt.Fprintf(out, "volume \"%v\" created (dry run)\n", opts.Name)
 		}
+		return nil
 	}
+
+	volCli, err := newVolumeClient(f, out)
+	if err != nil {
+		return err
+	}
+	volCreated, err := volCli.create(*opts)
+	if err != nil {
+		return err
+	}
+	if len(outputFormat) > 0 && outputFormat != "name" {
+		return printHyperRequest(out, outputFormat, volCreated)
+	}
+	fmt.Fprintf(out, "volume/%v\n", volCreated.Name)
 	return nil
 }
 
@@ -355,19 +419,57 @@ func RunCreateFipSubcommand(f cmdutil.Factory, cmd *cobra.Command, out io.Writer
 	if err != nil {
 		return err
 	}
-	opts := obj.(*hyper.FipAllocateRequest)
+	opts := obj.(*hyperclient.FipAllocateRequest)
+
+	dryRun := cmdutil.GetDryRunFlag(cmd)
+	outputFormat := cmdutil.GetFlagString(cmd, "output")
+	if dryRun {
+		if len(outputFormat) > 0 && outputFormat != "name" {
+			return printHyperRequest(out, outputFormat, opts)
+		}
+		fmt.Fprintf(out, "%d fip(s) allocated (dry run)\n", opts.Count)
+		return nil
+	}
+
 	if cfg, err := f.ClientConfig(); err != nil {
 		return err
 	} else {
-		hyperConn := hyper.NewHyperConn(cfg)
-		fipCli := hyper.NewFipCli(hyperConn)
-		if _, fipList, err := fipCli.AllocateFip(opts.Count); err != nil {
+		conn, err := hyper.NewConn(cfg)
+		if err != nil {
 			return err
+		}
+		if fipList, err := conn.AllocateFips(opts.Count); err != nil {
+			return err
+		} else if len(outputFormat) > 0 && outputFormat != "name" {
+			return printHyperRequest(out, outputFormat, fipList)
 		} else {
 			for _, fip := range fipList {
-				fmt.Printf("fip/%v\n", fip.Fip)
+				fmt.Fprintf(out, "fip/%v\n", fip.Fip)
 			}
 		}
 	}
 	return nil
 }
+
+// printHyperRequest prints a request or a response of the Hyper API, which have
+// no kind to be printed with the resource printers, as json or yaml
+func printHyperRequest(out io.Writer, outputFormat string, obj interface{}) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch outputFormat {
+	case "json":
+		data, err = json.MarshalIndent(obj, "", "    ")
+		data = append(data, '\n')
+	case "yaml":
+		data, err = yaml.Marshal(obj)
+	default:
+		return fmt.Errorf("output format %q not supported, allowed formats are: json,yaml,name", outputFormat)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = out.Write(data)
+	return err
+}
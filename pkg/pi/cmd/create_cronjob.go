@@ -0,0 +1,119 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/docker/distribution/reference"
+	"github.com/spf13/cobra"
+)
+
+var (
+	createCronJobLong = templates.LongDesc(i18n.T(`
+		Create a cronjob, which runs a job pod with particular image on a schedule.
+
+		The schedule is in the Cron format: minute hour day-of-month month day-of-week.
+		The image of a cronjob must be public.`))
+
+	createCronJobExample = templates.Examples(i18n.T(`
+		# Run the report image every 5 minutes.
+		pi create cronjob report --schedule="*/5 * * * *" --image=report
+
+		# Run the report image every night at 1:00, with a volume.
+		pi create cronjob nightly-report --schedule="0 1 * * *" --image=report --volume=reports:/data --size=m1
+
+		# Run a command every hour.
+		pi create cronjob cleanup --schedule="0 * * * *" --image=busybox -- rm -rf /data/tmp`))
+)
+
+func NewCmdCreateCronJob(f cmdutil.Factory, cmdOut, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "cronjob NAME --schedule=\"* * * * *\" --image=image [--env=\"key=value\"] [-- COMMAND [args...]]",
+		Short:   i18n.T("Run a job with particular image on a schedule."),
+		Aliases: []string{"cj"},
+		Long:    createCronJobLong,
+		Example: createCronJobExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			argsLenAtDash := cmd.ArgsLenAtDash()
+			err := RunCreateCronJob(f, cmdOut, cmdErr, cmd, args, argsLenAtDash)
+			cmdutil.CheckErr(err)
+		},
+	}
+	addJobPodFlags(cmd)
+	cmd.Flags().String("schedule", "", i18n.T("A schedule in the Cron format the job should be run with."))
+	cmd.MarkFlagRequired("schedule")
+	return cmd
+}
+
+// RunCreateCronJob creates a hyper cron from the job flags
+func RunCreateCronJob(f cmdutil.Factory, cmdOut, cmdErr io.Writer, cmd *cobra.Command, args []string, argsLenAtDash int) error {
+	if len(args) == 0 || argsLenAtDash == 0 {
+		return cmdutil.UsageErrorf(cmd, "NAME is required for cronjob")
+	}
+
+	imageName := cmdutil.GetFlagString(cmd, "image")
+	if imageName == "" {
+		return fmt.Errorf("--image is required")
+	}
+	if !reference.ReferenceRegexp.MatchString(imageName) {
+		return fmt.Errorf("Invalid image name %q: %v", imageName, reference.ErrReferenceInvalidFormat)
+	}
+
+	generatorName := cmdutil.HyperCronV1GeneratorName
+	generator, found := f.Generators("cronjob")[generatorName]
+	if !found {
+		return cmdutil.UsageErrorf(cmd, "generator %q not found", generatorName)
+	}
+	names := generator.ParamNames()
+	params := pi.MakeParams(cmd, names)
+	params["name"] = args[0]
+	if len(args) > 1 {
+		params["args"] = args[1:]
+	}
+	params["env"] = cmdutil.GetFlagStringArray(cmd, "env")
+	params["volume"] = cmdutil.GetFlagStringArray(cmd, "volume")
+
+	obj, err := generator.Generate(params)
+	if err != nil {
+		return err
+	}
+	cron := obj.(*pi.Cron)
+
+	cfg, err := f.ClientConfig()
+	if err != nil {
+		return err
+	}
+	cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, cmdOut, cmdErr)
+	if err != nil {
+		return err
+	}
+	created, err := cli.Client.CronCreate(context.Background(), cron.Name, cron.Cron)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmdOut, "cronjob/%v\n", created.Name)
+	return nil
+}
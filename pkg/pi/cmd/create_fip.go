@@ -41,6 +41,8 @@ func NewCmdCreateFip(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command
 		},
 	}
 	//cmdutil.AddGeneratorFlags(cmd, cmdutil.HyperFipV1GeneratorName)
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|name")
 	cmd.Flags().IntP("count", "c", 1, "Specify the count of fip to allocate, default is 1")
 	return cmd
 }
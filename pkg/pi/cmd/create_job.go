@@ -58,7 +58,7 @@ func NewCmdCreateJob(f cmdutil.Factory, cmdOut, cmdErr io.Writer) *cobra.Command
 			cmdutil.CheckErr(err)
 		},
 	}
-	//cmdutil.AddPrinterFlags(cmd)
+	cmdutil.AddPrinterFlags(cmd)
 	addCreateJobFlags(cmd)
 	//cmdutil.AddApplyAnnotationFlags(cmd)
 	//cmdutil.AddRecordFlag(cmd)
@@ -68,25 +68,23 @@ func NewCmdCreateJob(f cmdutil.Factory, cmdOut, cmdErr io.Writer) *cobra.Command
 }
 
 func addCreateJobFlags(cmd *cobra.Command) {
-	//cmdutil.AddDryRunFlag(cmd)
+	addJobPodFlags(cmd)
+	// the cron API has no field for the registry credentials, only jobs pull private images
+	cmd.Flags().StringP("image-pull-secrets", "", "", i18n.T("The secret for the private docker registry, comma separated."))
+	cmdutil.AddDryRunFlag(cmd)
 	//cmd.Flags().String("generator", "", i18n.T("The name of the API generator to use, see http://kubernetes.io/docs/user-guide/pi-conventions/#generators for a list."))
-	cmd.Flags().String("image", "", i18n.T("The image for the container to run."))
-	cmd.MarkFlagRequired("image")
 	cmd.Flags().String("image-pull-policy", "", i18n.T("The image pull policy for the container. If left empty, this value will not be specified by the client and defaulted by the server"))
 	//cmd.Flags().IntP("replicas", "r", 1, "Number of replicas to create for this container. Default is 1.")
 	//cmd.Flags().Bool("rm", false, "If true, delete resources created in this command for attached containers.")
 	//cmd.Flags().String("overrides", "", i18n.T("An inline JSON override for the generated object. If this is non-empty, it is used to override the generated object. Requires that the object supply a valid apiVersion field."))
-	cmd.Flags().StringArray("env", []string{}, "Environment variables to set in the container")
 	//cmd.Flags().String("serviceaccount", "", "Service account to set in the pod spec")
 	//cmd.Flags().String("port", "", i18n.T("The port that this container exposes.  If --expose is true, this is also the port used by the service that is created."))
 	//cmd.Flags().Int("hostport", -1, "The host port mapping for the container port. To demonstrate a single-machine container.")
-	cmd.Flags().StringP("labels", "l", "", "Comma separated labels to apply to the job(s). Will override previous values.")
 	//cmd.Flags().BoolP("stdin", "i", false, "Keep stdin open on the container(s) in the pod, even if nothing is attached.")
 	//cmd.Flags().BoolP("tty", "t", false, "Allocated a TTY for each container in the pod.")
 	//cmd.Flags().Bool("attach", false, "If true, wait for the Pod to start running, and then attach to the Pod as if 'pi attach ...' were called.  Default false, unless '-i/--stdin' is set, in which case the default is true. With '--restart=Never' the exit code of the container process is returned.")
 	//cmd.Flags().Bool("leave-stdin-open", false, "If the pod is started in interactive mode or with stdin, leave stdin open after the first attach completes. By default, stdin will be closed after the first attach completes.")
 	cmd.Flags().String("restart", "OnFailure", i18n.T("The restart policy for this Pod.  Legal values [OnFailure, Never]. if set to 'Never', a regular pod is created. Default 'OnFailure'"))
-	cmd.Flags().Bool("command", false, "If true and extra arguments are present, use them as the 'command' field in the container, rather than the 'args' field which is the default.")
 	//cmd.Flags().String("requests", "", i18n.T("The resource requirement requests for this container.  For example, 'cpu=100m,memory=256Mi'.  Note that server side components may assign requests depending on the server configuration, such as limit ranges."))
 	cmd.Flags().String("limits", "", i18n.T("The resource requirement limits for this container.  For example, 'cpu=200m,memory=512Mi'.  Note that server side components may assign limits depending on the server configuration, such as limit ranges."))
 	//cmd.Flags().Bool("expose", false, "If true, a public, external service is created for the container(s) which are run")
@@ -99,10 +97,20 @@ func addCreateJobFlags(cmd *cobra.Command) {
 	cmd.Flags().String("parallelism", "", "The concurrent pods to run for a job, default=1")
 	cmd.Flags().String("backoff-limit", "", "Specify the number of retries before considering a Job as failed.")
 
-	cmd.Flags().StringP("image-pull-secrets", "", "", i18n.T("The secret for the private docker registry, comma separated."))
 	cmd.Flags().StringP("active-deadline-seconds", "", "", i18n.T("Optional duration in seconds the pod may be active on the node relative to StartTime before the system will actively try to mark it failed and kill associated containers. Value must be a positive integer."))
+}
+
+// addJobPodFlags adds the flags describing the pod of a job, they are shared
+// by 'pi create job' and 'pi create cronjob'.
+func addJobPodFlags(cmd *cobra.Command) {
+	cmd.Flags().String("image", "", i18n.T("The image for the container to run."))
+	cmd.MarkFlagRequired("image")
+	cmd.Flags().StringArray("env", []string{}, "Environment variables to set in the container")
+	cmd.Flags().StringP("labels", "l", "", "Comma separated labels to apply to the job(s). Will override previous values.")
+	cmd.Flags().Bool("command", false, "If true and extra arguments are present, use them as the 'command' field in the container, rather than the 'args' field which is the default.")
 	cmd.Flags().StringP("size", "", "s4", i18n.T("The size for the pod (e.g. s1, s2, s3, s4, m1, m2, m3, l1, l2, l3, l4, l5, l6), you can not use --limits together with --size"))
 	cmd.Flags().StringArray("volume", []string{}, "Pod volumes to mount into the container's filesystem. format '<volname>:<path>'")
+	cmd.Flags().String("security-group", "", i18n.T("The security groups of the pod, comma separated. Create them with 'pi create securitygroup'."))
 }
 
 func RunJobRun(f cmdutil.Factory, cmdOut, cmdErr io.Writer, cmd *cobra.Command, args []string, argsLenAtDash int) error {
@@ -165,6 +173,7 @@ func RunJobRun(f cmdutil.Factory, cmdOut, cmdErr io.Writer, cmd *cobra.Command,
 		return cmdutil.UsageErrorf(cmd, "--size and --limits can not be used together")
 	}
 	params["volume"] = cmdutil.GetFlagStringArray(cmd, "volume")
+	params["security-group"] = cmdutil.GetFlagString(cmd, "security-group")
 
 	params["completions"] = cmdutil.GetFlagString(cmd, "completions")
 	params["parallelism"] = cmdutil.GetFlagString(cmd, "parallelism")
@@ -178,7 +187,5 @@ func RunJobRun(f cmdutil.Factory, cmdOut, cmdErr io.Writer, cmd *cobra.Command,
 	}
 	runObjectMap[generatorName] = runObject
 
-	f.PrintSuccess(runObject.Mapper, false, cmdOut, runObject.Mapping.Resource, args[0], false, "created")
-
-	return nil
+	return printRunObject(f, cmd, cmdOut, runObject, args[0])
 }
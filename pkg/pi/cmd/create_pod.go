@@ -64,6 +64,7 @@ func NewCmdCreatePod(f cmdutil.Factory, cmdIn io.Reader, cmdOut, errOut io.Write
 		},
 	}
 
+	cmdutil.AddPrinterFlags(cmd)
 	AddRunFlags(cmd)
 
 	return cmd
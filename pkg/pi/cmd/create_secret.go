@@ -87,8 +87,9 @@ func NewCmdCreateSecretGeneric(f cmdutil.Factory, cmdOut io.Writer) *cobra.Comma
 	}
 	//cmdutil.AddApplyAnnotationFlags(cmd)
 	//cmdutil.AddValidateFlags(cmd)
-	//cmdutil.AddPrinterFlags(cmd)
+	cmdutil.AddPrinterFlags(cmd)
 	//cmdutil.AddGeneratorFlags(cmd, cmdutil.SecretV1GeneratorName)
+	cmdutil.AddDryRunFlag(cmd)
 	cmd.Flags().StringSlice("from-file", []string{}, "Key files can be specified using their file path, in which case a default name will be given to them, or optionally with a name and file path, in which case the given name will be used.  Specifying a directory will iterate each named file in the directory that is a valid secret key.")
 	cmd.Flags().StringArray("from-literal", []string{}, "Specify a key and literal value to insert in secret (i.e. mykey=somevalue)")
 	cmd.Flags().String("from-env-file", "", "Specify the path to a file to read lines of key=val pairs to create a secret (i.e. a Docker .env file).")
@@ -158,8 +159,9 @@ func NewCmdCreateSecretDockerRegistry(f cmdutil.Factory, cmdOut io.Writer) *cobr
 	}
 	//cmdutil.AddApplyAnnotationFlags(cmd)
 	//cmdutil.AddValidateFlags(cmd)
-	//cmdutil.AddPrinterFlags(cmd)
+	cmdutil.AddPrinterFlags(cmd)
 	//cmdutil.AddGeneratorFlags(cmd, cmdutil.SecretForDockerRegistryV1GeneratorName)
+	cmdutil.AddDryRunFlag(cmd)
 	cmd.Flags().String("docker-username", "", i18n.T("Username for Docker registry authentication"))
 	cmd.MarkFlagRequired("docker-username")
 	cmd.Flags().String("docker-password", "", i18n.T("Password for Docker registry authentication"))
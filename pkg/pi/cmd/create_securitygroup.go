@@ -0,0 +1,120 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// NewCmdCreateSecurityGroup is a command to create a security group from a file
+func NewCmdCreateSecurityGroup(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "securitygroup [NAME] -f FILENAME",
+		Short:   i18n.T("Create a security group from a file"),
+		Aliases: []string{"sg"},
+		Long:    securityGroupLong,
+		Example: securityGroupExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := CreateSecurityGroupGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringP("filename", "f", "", "Filename of the security group, in YAML or JSON format")
+	cmd.MarkFlagRequired("filename")
+	return cmd
+}
+
+var (
+	securityGroupLong = templates.LongDesc(i18n.T(`
+		Create a security group from a file.
+
+		The file holds the name, the description and the rules of the security group. A pod
+		joins a security group with 'pi run --security-group', or with the label
+		sh_hyper_sgroup_<name>=yes.`))
+
+	securityGroupExample = templates.Examples(i18n.T(`
+	  # Create the security group described in sg.yaml
+	  pi create securitygroup -f sg.yaml
+
+	  # sg.yaml, only allow http and https from everywhere
+	  name: web
+	  description: allow http and https
+	  rules:
+	  - direction: ingress
+	    protocol: tcp
+	    port_range_min: 80
+	    port_range_max: 80
+	    remote_ip_prefix: 0.0.0.0/0
+	  - direction: ingress
+	    protocol: tcp
+	    port_range_min: 443
+	    port_range_max: 443
+	    remote_ip_prefix: 0.0.0.0/0`))
+)
+
+// CreateSecurityGroupGeneric is the implementation of the create securitygroup command
+func CreateSecurityGroupGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	filename := cmdutil.GetFlagString(cmd, "filename")
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	// YAML is a superset of JSON, both are parsed here
+	var sg types.SecurityGroup
+	if err := yaml.Unmarshal(data, &sg); err != nil {
+		return fmt.Errorf("failed to parse %s, error:%v", filename, err)
+	}
+	if len(args) > 0 {
+		sg.GroupName = args[0]
+	}
+	if sg.GroupName == "" {
+		return cmdutil.UsageErrorf(cmd, "NAME is required, either as an argument or as the name in %s", filename)
+	}
+	body, err := json.Marshal(sg)
+	if err != nil {
+		return err
+	}
+
+	if cfg, err := f.ClientConfig(); err != nil {
+		return err
+	} else {
+		cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		if err := cli.Client.SgCreate(context.Background(), sg.GroupName, bytes.NewReader(body)); err != nil {
+			return err
+		}
+		fmt.Printf("securitygroup/%v\n", sg.GroupName)
+	}
+	return nil
+}
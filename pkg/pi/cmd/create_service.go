@@ -75,8 +75,9 @@ func NewCmdCreateServiceClusterIP(f cmdutil.Factory, cmdOut io.Writer) *cobra.Co
 	}
 	//cmdutil.AddApplyAnnotationFlags(cmd)
 	//cmdutil.AddValidateFlags(cmd)
-	//cmdutil.AddPrinterFlags(cmd)
+	cmdutil.AddPrinterFlags(cmd)
 	//cmdutil.AddGeneratorFlags(cmd, cmdutil.ServiceClusterIPGeneratorV1Name)
+	cmdutil.AddDryRunFlag(cmd)
 	addPortFlags(cmd)
 	cmd.Flags().String("clusterip", "", i18n.T("Assign your own ClusterIP or set to 'None' for a 'headless' service (no loadbalancing)."))
 	cmd.Flags().StringSliceP("selector", "l", []string{}, "Labels selectors for pods")
@@ -190,8 +191,9 @@ func NewCmdCreateServiceLoadBalancer(f cmdutil.Factory, cmdOut io.Writer) *cobra
 	}
 	//cmdutil.AddApplyAnnotationFlags(cmd)
 	//cmdutil.AddValidateFlags(cmd)
-	//cmdutil.AddPrinterFlags(cmd)
+	cmdutil.AddPrinterFlags(cmd)
 	//cmdutil.AddGeneratorFlags(cmd, cmdutil.ServiceLoadBalancerGeneratorV1Name)
+	cmdutil.AddDryRunFlag(cmd)
 	addPortFlags(cmd)
 	cmd.Flags().StringP("loadbalancerip", "f", "", "Set fip as LoadBalancerIP")
 	cmd.Flags().StringSliceP("selector", "l", []string{}, "Labels selectors for pods")
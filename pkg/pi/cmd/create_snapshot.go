@@ -0,0 +1,108 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdCreateSnapshot is a command to create a snapshot of a volume
+func NewCmdCreateSnapshot(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "snapshot NAME --volume=string [--force]",
+		Short:   i18n.T("Create a snapshot of a volume"),
+		Long:    snapshotLong,
+		Example: snapshotExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := CreateSnapshotGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().String("volume", "", i18n.T("The volume to take the snapshot of"))
+	cmd.Flags().Bool("force", false, "Take the snapshot even if the volume is in use by a pod")
+	cmd.MarkFlagRequired("volume")
+	cmdutil.AddOutputFlags(cmd)
+	return cmd
+}
+
+var (
+	snapshotLong = templates.LongDesc(i18n.T(`
+		Create a snapshot of a volume.
+
+		The snapshot can be used to create a new volume with 'pi create volume --from-snapshot'.`))
+
+	snapshotExample = templates.Examples(i18n.T(`
+	  # Create a snapshot named snap1 of the volume mysql-data
+	  pi create snapshot snap1 --volume=mysql-data
+
+	  # Create a snapshot named snap1 of the volume mysql-data, and print it in YAML
+	  pi create snapshot snap1 --volume=mysql-data -o yaml
+
+	  # Create a new volume named mysql-restore from the snapshot snap1
+	  pi create volume mysql-restore --from-snapshot=snap1`))
+)
+
+// CreateSnapshotGeneric is the implementation of the create snapshot command
+func CreateSnapshotGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	name, err := NameFromCommandArgs(cmd, args)
+	if err != nil {
+		return err
+	}
+	volume := cmdutil.GetFlagString(cmd, "volume")
+	if volume == "" {
+		return cmdutil.UsageErrorf(cmd, "--volume is required")
+	}
+	outputFormat := cmdutil.GetFlagString(cmd, "output")
+	switch outputFormat {
+	case "", "name", "json", "yaml":
+	default:
+		return cmdutil.UsageErrorf(cmd, "output format %q not supported, allowed formats are: json,yaml,name", outputFormat)
+	}
+
+	if cfg, err := f.ClientConfig(); err != nil {
+		return err
+	} else {
+		cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		snapshot, err := cli.Client.SnapshotCreate(context.Background(), types.SnapshotCreateRequest{
+			Name:   name,
+			Volume: volume,
+			Force:  cmdutil.GetFlagBool(cmd, "force"),
+		})
+		if err != nil {
+			return err
+		}
+		if len(outputFormat) > 0 && outputFormat != "name" {
+			return printHyperRequest(cmdOut, outputFormat, snapshot)
+		}
+		fmt.Fprintf(cmdOut, "snapshot/%v\n", snapshot.Name)
+	}
+	return nil
+}
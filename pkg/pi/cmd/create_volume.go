@@ -30,7 +30,7 @@ import (
 // NewCmdCreateVolume groups subcommands to create various zones of volumes
 func NewCmdCreateVolume(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "volume NAME [--zone=string] [--size=int]",
+		Use:     "volume NAME [--zone=string] [--size=int] [--from-snapshot=string]",
 		Short:   i18n.T("Create a volume using specified subcommand"),
 		Long:    volumeLong,
 		Example: volumeExample,
@@ -43,9 +43,12 @@ func NewCmdCreateVolume(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Comm
 	//cmdutil.AddValidateFlags(cmd)
 	//cmdutil.AddPrinterFlags(cmd)
 	//cmdutil.AddGeneratorFlags(cmd, cmdutil.HyperVolumeV1GeneratorName)
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|name")
 
 	cmd.Flags().String("size", "", "Specify the volume size, default 10(GB), min 1, max 1024")
 	cmd.Flags().String("zone", "", i18n.T("The zone of volume to create"))
+	cmd.Flags().String("from-snapshot", "", i18n.T("The snapshot to create the volume from, the size defaults to the size of the snapshot"))
 	return cmd
 }
 
@@ -60,7 +63,10 @@ var (
 	  pi create volume vol1 --size=1
 
 	  # Create a new volume named vol1 with specified size and zone
-	  pi create volume vol1 --size=1 --zone=gcp-us-central1`))
+	  pi create volume vol1 --size=1 --zone=gcp-us-central1
+
+	  # Create a new volume named vol1 from the snapshot snap1
+	  pi create volume vol1 --from-snapshot=snap1`))
 )
 
 // CreateVolumeGeneric is the implementation of the create volume generic command
@@ -70,16 +76,18 @@ func CreateVolumeGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command
 		return err
 	}
 	size := cmdutil.GetFlagString(cmd, "size")
-	if size == "" {
+	snapshot := cmdutil.GetFlagString(cmd, "from-snapshot")
+	if size == "" && snapshot == "" {
 		size = "10"
 	}
 	var generator pi.StructuredGenerator
 	switch generatorName := cmdutil.HyperVolumeV1GeneratorName; generatorName {
 	case cmdutil.HyperVolumeV1GeneratorName:
 		generator = &pi.VolumeGeneratorV1{
-			Name: name,
-			Size: size,
-			Zone: cmdutil.GetFlagString(cmd, "zone"),
+			Name:     name,
+			Size:     size,
+			Zone:     cmdutil.GetFlagString(cmd, "zone"),
+			Snapshot: snapshot,
 		}
 	default:
 		return errUnsupportedGenerator(cmd, generatorName)
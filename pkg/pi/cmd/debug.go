@@ -0,0 +1,387 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/docker/distribution/reference"
+	dockerterm "github.com/docker/docker/pkg/term"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	api "k8s.io/kubernetes/pkg/apis/core"
+	coreclient "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset/typed/core/internalversion"
+)
+
+var (
+	debugLong = templates.LongDesc(i18n.T(`
+		Debug a pod with an interactive shell in a throwaway pod.
+
+		The debug pod runs the given image in the zone of the pod, with the labels of the pod, so
+		that its services route to the debug pod too. The flexVolumes of the pod are mounted
+		read-only at the same paths where possible, the debug pod is started without them
+		otherwise.
+
+		With --copy-to the debug pod is a copy of the pod instead, with the command of the
+		container replaced by the shell, and its image by --image if given.
+
+		The debug pod is deleted when the shell exits, unless --rm=false.`))
+
+	debugExample = templates.Examples(i18n.T(`
+		# Debug the pod web with a busybox shell
+		pi debug web --image=busybox
+
+		# Run ash instead of sh in the debug pod
+		pi debug web --image=alpine -- ash
+
+		# Debug a copy of the pod web, with the image of the nginx container replaced by busybox
+		pi debug web --copy-to=web-debug --image=busybox -c nginx
+
+		# Keep the debug pod after the shell exits, without the labels of the pod
+		pi debug web --image=busybox --rm=false --share-labels=false`))
+)
+
+// debugContainerName is the name of the container of the throwaway debug pod
+const debugContainerName = "debug"
+
+// DebugOptions declare the arguments accepted by the Debug command
+type DebugOptions struct {
+	Namespace     string
+	PodName       string
+	ContainerName string
+	Image         string
+	CopyTo        string
+	Size          string
+	Command       []string
+	Remove        bool
+	ShareLabels   bool
+	ShareVolumes  bool
+	TTY           bool
+	Timeout       time.Duration
+
+	PodClient coreclient.CoreInterface
+
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// NewCmdDebug creates a command to debug a pod in a throwaway pod
+func NewCmdDebug(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer) *cobra.Command {
+	options := &DebugOptions{
+		In:  cmdIn,
+		Out: cmdOut,
+		Err: cmdErr,
+	}
+	cmd := &cobra.Command{
+		Use:     "debug POD --image=image [--copy-to=NAME] [-c CONTAINER] -- [COMMAND] [args...]",
+		Short:   i18n.T("Debug a pod with an interactive shell in a throwaway pod"),
+		Long:    debugLong,
+		Example: debugExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args, cmd.ArgsLenAtDash()))
+			cmdutil.CheckErr(options.Run(f))
+		},
+	}
+	cmdutil.AddPodRunningTimeoutFlag(cmd, defaultPodAttachTimeout)
+	cmd.Flags().StringVar(&options.Image, "image", "", i18n.T("The image of the debug container, required unless --copy-to is set"))
+	cmd.Flags().StringVarP(&options.ContainerName, "container", "c", "", "Container name. If omitted, the first container in the pod will be chosen")
+	cmd.Flags().StringVar(&options.CopyTo, "copy-to", "", i18n.T("Debug a copy of the pod with this name, rather than a new pod"))
+	cmd.Flags().StringVar(&options.Size, "size", "", i18n.T("The size of the debug pod (e.g. s1, s2, s3, s4, m1, m2, m3, l1, l2, l3, l4, l5, l6), defaults to the size of the pod"))
+	cmd.Flags().BoolVar(&options.Remove, "rm", true, "If true, delete the debug pod when the shell exits")
+	cmd.Flags().BoolVar(&options.ShareLabels, "share-labels", true, "If true, the debug pod has the labels of the pod, and is a backend of its services")
+	cmd.Flags().BoolVar(&options.ShareVolumes, "share-volumes", true, "If true, the flexVolumes of the pod are mounted read-only in the debug pod")
+	cmd.Flags().BoolVarP(&options.TTY, "tty", "t", true, "Allocate a TTY for the shell, when stdin is a terminal")
+	return cmd
+}
+
+// Complete completes all the required options for debug.
+func (o *DebugOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string, argsLenAtDash int) error {
+	if len(args) == 0 || argsLenAtDash == 0 {
+		return cmdutil.UsageErrorf(cmd, "POD is required for debug")
+	}
+	o.PodName = args[0]
+	o.Command = []string{"sh"}
+	if len(args) > 1 {
+		o.Command = args[1:]
+	}
+	if len(o.Image) == 0 && len(o.CopyTo) == 0 {
+		return cmdutil.UsageErrorf(cmd, "--image is required, unless --copy-to is set")
+	}
+	if len(o.Image) > 0 && !reference.ReferenceRegexp.MatchString(o.Image) {
+		return fmt.Errorf("Invalid image name %q: %v", o.Image, reference.ErrReferenceInvalidFormat)
+	}
+	if _, isTerminal := dockerterm.GetFdInfo(os.Stdin); !isTerminal {
+		o.TTY = false
+	}
+
+	timeout, err := cmdutil.GetPodRunningTimeoutFlag(cmd)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, "%v", err)
+	}
+	o.Timeout = timeout
+
+	namespace, _, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	clientset, err := f.ClientSet()
+	if err != nil {
+		return err
+	}
+	o.PodClient = clientset.Core()
+	return nil
+}
+
+// Run starts the debug pod and runs the shell in it.
+func (o *DebugOptions) Run(f cmdutil.Factory) error {
+	pod, err := o.PodClient.Pods(o.Namespace).Get(o.PodName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	container, err := podContainer(pod, o.ContainerName)
+	if err != nil {
+		return err
+	}
+
+	var debugPod *api.Pod
+	var debugContainer string
+	if len(o.CopyTo) > 0 {
+		debugPod, debugContainer = o.copyPod(pod, container)
+	} else {
+		debugPod, debugContainer = o.newPod(pod, container)
+	}
+	created, err := o.PodClient.Pods(o.Namespace).Create(debugPod)
+	if isFlexVolumeError(&debugPod.Spec, err) && removeFlexVolumes(&debugPod.Spec) {
+		fmt.Fprintf(o.Err, "Unable to mount the volumes of pod %s read-only (%v), starting pod %s without them.\n", pod.Name, err, debugPod.Name)
+		created, err = o.PodClient.Pods(o.Namespace).Create(debugPod)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Err, "Debugging pod %s in pod %s.\n", pod.Name, created.Name)
+	if o.Remove {
+		defer deletePod(created.Name, o.PodClient)
+	}
+
+	if err := waitForPodRunning(o.PodClient, o.Namespace, created.Name, o.Timeout); err != nil {
+		return err
+	}
+
+	options := &ExecOptions{
+		StreamOptions: StreamOptions{
+			In:            o.In,
+			Out:           o.Out,
+			Err:           o.Err,
+			PodName:       created.Name,
+			ContainerName: debugContainer,
+			Namespace:     o.Namespace,
+			TTY:           o.TTY,
+			Stdin:         true,
+		},
+		Executor: &DefaultRemoteExecutor{},
+		Command:  o.Command,
+	}
+	options.PodClient = o.PodClient
+	return options.RunHyper(f)
+}
+
+// newPod returns the throwaway debug pod of pod, and the name of its container
+func (o *DebugOptions) newPod(pod *api.Pod, container *api.Container) (*api.Pod, string) {
+	debugPod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        fmt.Sprintf("%s-debug-%s", pod.Name, utilrand.String(5)),
+			Annotations: map[string]string{},
+		},
+		Spec: api.PodSpec{
+			Containers: []api.Container{
+				{
+					Name:            debugContainerName,
+					Image:           o.Image,
+					ImagePullPolicy: api.PullIfNotPresent,
+					Command:         o.Command,
+					Stdin:           true,
+					TTY:             o.TTY,
+				},
+			},
+			RestartPolicy:    api.RestartPolicyNever,
+			ImagePullSecrets: pod.Spec.ImagePullSecrets,
+		},
+	}
+	if o.ShareLabels {
+		debugPod.Labels = pod.Labels
+	}
+	if size, found := pod.Annotations["sh_hyper_instancetype"]; found {
+		debugPod.Annotations["sh_hyper_instancetype"] = size
+	}
+	if len(o.Size) > 0 {
+		debugPod.Annotations["sh_hyper_instancetype"] = o.Size
+	}
+	if zone, found := pod.Spec.NodeSelector["zone"]; found {
+		debugPod.Spec.NodeSelector = map[string]string{"zone": zone}
+	}
+
+	if o.ShareVolumes {
+		flexVolumes := map[string]bool{}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.FlexVolume == nil {
+				continue
+			}
+			flexVolumes[volume.Name] = true
+			volume.FlexVolume = volume.FlexVolume.DeepCopy()
+			volume.FlexVolume.ReadOnly = true
+			debugPod.Spec.Volumes = append(debugPod.Spec.Volumes, volume)
+		}
+		for _, mount := range container.VolumeMounts {
+			if flexVolumes[mount.Name] {
+				mount.ReadOnly = true
+				debugPod.Spec.Containers[0].VolumeMounts = append(debugPod.Spec.Containers[0].VolumeMounts, mount)
+			}
+		}
+	}
+	return debugPod, debugContainerName
+}
+
+// copyPod returns the copy of pod named after --copy-to, with the shell as the
+// command of container, and the name of the container
+func (o *DebugOptions) copyPod(pod *api.Pod, container *api.Container) (*api.Pod, string) {
+	debugPod := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        o.CopyTo,
+			Annotations: pod.Annotations,
+		},
+		Spec: *pod.Spec.DeepCopy(),
+	}
+	if o.ShareLabels {
+		debugPod.Labels = pod.Labels
+	}
+	if len(o.Size) > 0 {
+		debugPod.Annotations = map[string]string{}
+		for key, value := range pod.Annotations {
+			debugPod.Annotations[key] = value
+		}
+		debugPod.Annotations["sh_hyper_instancetype"] = o.Size
+	}
+	debugPod.Spec.NodeName = ""
+	debugPod.Spec.RestartPolicy = api.RestartPolicyNever
+
+	for i := range debugPod.Spec.Containers {
+		c := &debugPod.Spec.Containers[i]
+		if c.Name != container.Name {
+			continue
+		}
+		if len(o.Image) > 0 {
+			c.Image = o.Image
+		}
+		c.Command = o.Command
+		c.Args = nil
+		c.Stdin = true
+		c.TTY = o.TTY
+		c.LivenessProbe = nil
+		c.ReadinessProbe = nil
+	}
+
+	if !o.ShareVolumes {
+		removeFlexVolumes(&debugPod.Spec)
+		return debugPod, container.Name
+	}
+	flexVolumes := map[string]bool{}
+	for _, volume := range debugPod.Spec.Volumes {
+		if volume.FlexVolume != nil {
+			flexVolumes[volume.Name] = true
+			volume.FlexVolume.ReadOnly = true
+		}
+	}
+	for i := range debugPod.Spec.Containers {
+		mounts := debugPod.Spec.Containers[i].VolumeMounts
+		for j := range mounts {
+			if flexVolumes[mounts[j].Name] {
+				mounts[j].ReadOnly = true
+			}
+		}
+	}
+	return debugPod, container.Name
+}
+
+// podContainer returns the container name of pod, or its first container
+func podContainer(pod *api.Pod, name string) (*api.Container, error) {
+	if len(name) == 0 {
+		return &pod.Spec.Containers[0], nil
+	}
+	for i := range pod.Spec.Containers {
+		if pod.Spec.Containers[i].Name == name {
+			return &pod.Spec.Containers[i], nil
+		}
+	}
+	return nil, fmt.Errorf("container %s is not valid for pod %s", name, pod.Name)
+}
+
+// isFlexVolumeError returns true if err is the failure to attach a flexVolume of
+// spec, i.e. an error about a volume naming one of the flexVolumes or their volumes
+func isFlexVolumeError(spec *api.PodSpec, err error) bool {
+	if err == nil || !strings.Contains(strings.ToLower(err.Error()), "volume") {
+		return false
+	}
+	for _, volume := range spec.Volumes {
+		if volume.FlexVolume == nil {
+			continue
+		}
+		if strings.Contains(err.Error(), volume.Name) {
+			return true
+		}
+		if volumeID := volume.FlexVolume.Options["volumeID"]; len(volumeID) > 0 && strings.Contains(err.Error(), volumeID) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFlexVolumes removes the flexVolumes of spec and their mounts, it returns
+// false if spec has no flexVolume
+func removeFlexVolumes(spec *api.PodSpec) bool {
+	flexVolumes := map[string]bool{}
+	volumes := []api.Volume{}
+	for _, volume := range spec.Volumes {
+		if volume.FlexVolume != nil {
+			flexVolumes[volume.Name] = true
+			continue
+		}
+		volumes = append(volumes, volume)
+	}
+	spec.Volumes = volumes
+	for i := range spec.Containers {
+		mounts := []api.VolumeMount{}
+		for _, mount := range spec.Containers[i].VolumeMounts {
+			if !flexVolumes[mount.Name] {
+				mounts = append(mounts, mount)
+			}
+		}
+		spec.Containers[i].VolumeMounts = mounts
+	}
+	return len(flexVolumes) > 0
+}
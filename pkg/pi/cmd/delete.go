@@ -32,7 +32,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/wait"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
 var (
@@ -75,7 +75,10 @@ var (
 		pi delete pod foo --grace-period=0 --force
 
 		# Delete all pods
-		pi delete pods --all`))
+		pi delete pods --all
+
+		# Delete the pods, services, fips and volumes of a stack
+		pi delete -f examples/wordpress/stack.tpl.yaml`))
 )
 
 type DeleteOptions struct {
@@ -138,8 +141,9 @@ func NewCmdDelete(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
 		ValidArgs:  validArgs,
 		ArgAliases: argAliases,
 	}
-	//usage := "containing the resource to delete."
-	//cmdutil.AddFilenameOptionFlags(cmd, &options.FilenameOptions, usage)
+	usage := "containing the resource to delete."
+	cmdutil.AddFilenameOptionFlags(cmd, &options.FilenameOptions, usage)
+	cmdutil.AddTemplateParamFlags(cmd)
 	//cmd.Flags().StringVarP(&options.Selector, "selector", "l", "", "Selector (label query) to filter on, not including uninitialized ones.")
 	cmd.Flags().BoolVar(&options.DeleteAll, "all", false, "Delete all resources, including uninitialized ones, in the namespace of the specified resource types.")
 	cmd.Flags().BoolVar(&options.IgnoreNotFound, "ignore-not-found", false, "Treat \"resource not found\" as a successful delete. Defaults to \"true\" when --all is specified.")
@@ -154,6 +158,9 @@ func NewCmdDelete(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
 	// delete volume, fip
 	cmd.AddCommand(NewCmdDeleteVolume(f, out, errOut))
 	cmd.AddCommand(NewCmdDeleteFip(f, out, errOut))
+	cmd.AddCommand(NewCmdDeleteSnapshot(f, out, errOut))
+	cmd.AddCommand(NewCmdDeleteSecurityGroup(f, out, errOut))
+	cmd.AddCommand(NewCmdDeleteCronJob(f, out, errOut))
 	return cmd
 }
 
@@ -163,15 +170,20 @@ func (o *DeleteOptions) Complete(f cmdutil.Factory, out, errOut io.Writer, args
 		return err
 	}
 
-	if len(args) == 0 {
+	if len(args) == 0 && cmdutil.IsFilenameSliceEmpty(o.FilenameOptions.Filenames) {
 		usageString := fmt.Sprint("You must specify the type of resource to delete. ",
 			cmdutil.ValidDeleteResourceTypeList(f), "\nerror: Required resource not specified.")
 		return cmdutil.UsageErrorf(cmd, usageString)
 	}
 
+	if o.FilenameOptions.Params, err = cmdutil.GetTemplateParams(cmd); err != nil {
+		return err
+	}
+
 	//includeUninitialized := cmdutil.ShouldIncludeUninitialized(cmd, false)
 	r := f.NewBuilder().
 		Unstructured().
+		HyperObjects().
 		ContinueOnError().
 		NamespaceParam(cmdNamespace).DefaultNamespace().
 		FilenameParam(enforceNamespace, &o.FilenameOptions).
@@ -230,6 +242,9 @@ func (o *DeleteOptions) Validate(cmd *cobra.Command) error {
 
 func (o *DeleteOptions) RunDelete() error {
 	shortOutput := o.Output == "name"
+	if !cmdutil.IsFilenameSliceEmpty(o.FilenameOptions.Filenames) {
+		return o.deleteFiles(shortOutput)
+	}
 	// By default use a reaper to delete all related resources.
 	if o.Cascade {
 		return ReapResult(o.Result, o.f, o.Out, true, o.IgnoreNotFound, o.Timeout, o.GracePeriod, o.WaitForDeletion, shortOutput, o.Mapper, false)
@@ -311,6 +326,80 @@ func DeleteResult(r *resource.Result, f cmdutil.Factory, out io.Writer, ignoreNo
 	return nil
 }
 
+// deleteFiles deletes the objects of the files in the reverse order they are created
+// in. The volumes and fips are deleted once the pods and services using them are gone.
+func (o *DeleteOptions) deleteFiles(shortOutput bool) error {
+	r := o.Result
+	if o.IgnoreNotFound {
+		r = r.IgnoreErrors(errors.IsNotFound)
+	}
+	infos, err := r.Infos()
+	if err != nil {
+		return err
+	}
+	resource.SortByDependency(infos)
+
+	options := &metav1.DeleteOptions{}
+	if o.GracePeriod >= 0 {
+		options = metav1.NewDeleteOptions(int64(o.GracePeriod))
+	}
+	orphan := !o.Cascade
+	options.OrphanDependents = &orphan
+
+	found := 0
+	deleted := []*resource.Info{}
+	hyperObjects := []*resource.Info{}
+	for i := len(infos) - 1; i >= 0; i-- {
+		info := infos[i]
+		if resource.IsHyperObject(info) {
+			hyperObjects = append(hyperObjects, info)
+			continue
+		}
+		if err := deleteResource(info, o.f, o.Out, shortOutput, o.Mapper, options); err != nil {
+			if o.IgnoreNotFound && errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		found++
+		deleted = append(deleted, info)
+	}
+
+	if len(hyperObjects) > 0 {
+		timeout := o.Timeout
+		if timeout == 0 {
+			timeout = defaultStackDeletionTimeout
+		}
+		for _, info := range deleted {
+			if err := waitForObjectDeletion(info, timeout); err != nil {
+				return cmdutil.AddSourceToErr("deleting", info.Source, err)
+			}
+		}
+	}
+	// the volumes and fips are independent, a failed one does not stop the others
+	errs := []error{}
+	for _, info := range hyperObjects {
+		exists, err := deleteHyperObject(o.f, info)
+		if err != nil {
+			errs = append(errs, cmdutil.AddSourceToErr("deleting", info.Source, err))
+			continue
+		}
+		if !exists {
+			if !o.IgnoreNotFound {
+				errs = append(errs, cmdutil.AddSourceToErr("deleting", info.Source, fmt.Errorf("%s %q not found", hyperResourceNames[info.Mapping.GroupVersionKind.Kind], info.Name)))
+			}
+			continue
+		}
+		found++
+		printHyperSuccess(o.Out, shortOutput, info, false, "deleted")
+	}
+
+	if found == 0 && len(errs) == 0 {
+		fmt.Fprintf(o.Out, "No resources found\n")
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
 func cascadingDeleteResource(info *resource.Info, f cmdutil.Factory, out io.Writer, shortOutput bool, mapper meta.RESTMapper) error {
 	falseVar := false
 	deleteOptions := &metav1.DeleteOptions{OrphanDependents: &falseVar}
@@ -325,23 +414,6 @@ func deleteResource(info *resource.Info, f cmdutil.Factory, out io.Writer, short
 	return nil
 }
 
-// objectDeletionWaitInterval is the interval to wait between checks for deletion.
-var objectDeletionWaitInterval = time.Second
-
-// waitForObjectDeletion refreshes the object, waiting until it is deleted, a timeout is reached, or
-// an error is encountered. It checks once a second.
-func waitForObjectDeletion(info *resource.Info, timeout time.Duration) error {
-	copied := *info
-	info = &copied
-	// TODO: refactor Reaper so that we can pass the "wait" option into it, and then check for UID change.
-	return wait.PollImmediate(objectDeletionWaitInterval, timeout, func() (bool, error) {
-		switch err := info.Get(); {
-		case err == nil:
-			return false, nil
-		case errors.IsNotFound(err):
-			return true, nil
-		default:
-			return false, err
-		}
-	})
-}
+// defaultStackDeletionTimeout is the time to wait for the pods of the files to be
+// deleted before their volumes, when --timeout is not set.
+const defaultStackDeletionTimeout = 5 * time.Minute
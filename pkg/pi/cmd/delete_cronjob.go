@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdDeleteCronJob is a command to delete cronjobs
+func NewCmdDeleteCronJob(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	options := &DeleteOptions{}
+	cmd := &cobra.Command{
+		Use:     "cronjob NAME",
+		Short:   i18n.T("Delete cronjob(s)"),
+		Aliases: []string{"cronjobs", "cj"},
+		Long:    delCronJobLong,
+		Example: delCronJobExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := options.DeleteCronJobGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().BoolVar(&options.DeleteAll, "all", false, "Delete all cronjobs")
+	return cmd
+}
+
+var (
+	delCronJobLong = templates.LongDesc(i18n.T(`Delete cronjob(s).`))
+
+	delCronJobExample = templates.Examples(i18n.T(`
+	  # Delete a cronjob named report
+	  pi delete cronjob report
+
+	  # Delete multiple cronjobs
+	  pi delete cronjobs report cleanup`))
+)
+
+// DeleteCronJobGeneric is the implementation of the delete cronjob command
+func (o *DeleteOptions) DeleteCronJobGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 0 && o.DeleteAll {
+		return fmt.Errorf("name cannot be provided when --all is specified")
+	}
+	if len(args) == 0 && !o.DeleteAll {
+		return fmt.Errorf("resource(s) were provided, but no name or --all flag specified")
+	}
+
+	if cfg, err := f.ClientConfig(); err != nil {
+		return err
+	} else {
+		cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+
+		if o.DeleteAll {
+			cronList, err := cli.Client.CronList(ctx, types.CronListOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to list all cronjobs, error:%v", err)
+			}
+			for _, cron := range cronList {
+				args = append(args, cron.Name)
+			}
+		}
+
+		for _, name := range args {
+			if err := cli.Client.CronDelete(ctx, name); err != nil {
+				fmt.Println(err)
+			} else {
+				fmt.Printf("cronjob \"%v\" deleted\n", name)
+			}
+		}
+	}
+	return nil
+}
@@ -20,13 +20,13 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/pi/pkg/hyper"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
 
 	"github.com/spf13/cobra"
-	"net/http"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
 // NewCmdDeleteFip groups subcommands to delete various zones of fips
@@ -70,10 +70,12 @@ func (o *DeleteOptions) DeleteFipGeneric(f cmdutil.Factory, cmdOut io.Writer, cm
 	if cfg, err := f.ClientConfig(); err != nil {
 		return err
 	} else {
-		hyperConn := hyper.NewHyperConn(cfg)
-		fipCli := hyper.NewFipCli(hyperConn)
+		conn, err := hyper.NewConn(cfg)
+		if err != nil {
+			return err
+		}
 		if o.DeleteAll {
-			_, fipList, err := fipCli.ListFips()
+			fipList, err := conn.ListFips()
 			if err != nil {
 				return fmt.Errorf("failed to list all fips, error:%v", err)
 			}
@@ -82,16 +84,16 @@ func (o *DeleteOptions) DeleteFipGeneric(f cmdutil.Factory, cmdOut io.Writer, cm
 			}
 		}
 
+		errs := []error{}
 		for _, ip := range args {
-			httpStatus, result := fipCli.ReleaseFip(ip)
-			if httpStatus == http.StatusNoContent {
-				fmt.Printf("fip \"%v\" deleted\n", ip)
+			if err := conn.ReleaseFip(ip); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete fip %q, error:%v", ip, err))
 			} else {
-				fmt.Println(result)
+				fmt.Printf("fip \"%v\" deleted\n", ip)
 			}
 		}
+		return utilerrors.NewAggregate(errs)
 	}
-	return nil
 }
 
 func IPFromCommandArgs(cmd *cobra.Command, args []string) (string, error) {
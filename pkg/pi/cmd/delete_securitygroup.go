@@ -0,0 +1,99 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdDeleteSecurityGroup is a command to delete security groups
+func NewCmdDeleteSecurityGroup(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	options := &DeleteOptions{}
+	cmd := &cobra.Command{
+		Use:     "securitygroup NAME",
+		Short:   i18n.T("Delete security group(s)"),
+		Aliases: []string{"securitygroups", "sg"},
+		Long:    delSecurityGroupLong,
+		Example: delSecurityGroupExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := options.DeleteSecurityGroupGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().BoolVar(&options.DeleteAll, "all", false, "Delete all security groups")
+	return cmd
+}
+
+var (
+	delSecurityGroupLong = templates.LongDesc(i18n.T(`Delete security group(s).`))
+
+	delSecurityGroupExample = templates.Examples(i18n.T(`
+	  # Delete a security group named web
+	  pi delete securitygroup web
+
+	  # Delete multiple security groups
+	  pi delete securitygroups web db`))
+)
+
+// DeleteSecurityGroupGeneric is the implementation of the delete securitygroup command
+func (o *DeleteOptions) DeleteSecurityGroupGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 0 && o.DeleteAll {
+		return fmt.Errorf("name cannot be provided when --all is specified")
+	}
+	if len(args) == 0 && !o.DeleteAll {
+		return fmt.Errorf("resource(s) were provided, but no name or --all flag specified")
+	}
+
+	if cfg, err := f.ClientConfig(); err != nil {
+		return err
+	} else {
+		cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+
+		if o.DeleteAll {
+			sgList, err := cli.Client.SgLs(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to list all security groups, error:%v", err)
+			}
+			for _, sg := range sgList {
+				args = append(args, sg.GroupName)
+			}
+		}
+
+		for _, name := range args {
+			if err := cli.Client.SgRm(ctx, name); err != nil {
+				fmt.Println(err)
+			} else {
+				fmt.Printf("securitygroup \"%v\" deleted\n", name)
+			}
+		}
+	}
+	return nil
+}
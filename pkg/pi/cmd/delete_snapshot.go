@@ -0,0 +1,100 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hyperhq/hyper-api/types/filters"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdDeleteSnapshot is a command to delete snapshots
+func NewCmdDeleteSnapshot(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	options := &DeleteOptions{}
+	cmd := &cobra.Command{
+		Use:     "snapshot NAME",
+		Short:   i18n.T("Delete snapshot(s)"),
+		Aliases: []string{"snapshots"},
+		Long:    delSnapshotLong,
+		Example: delSnapshotExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := options.DeleteSnapshotGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().BoolVar(&options.DeleteAll, "all", false, "Delete all snapshots")
+	return cmd
+}
+
+var (
+	delSnapshotLong = templates.LongDesc(i18n.T(`Delete snapshot(s).`))
+
+	delSnapshotExample = templates.Examples(i18n.T(`
+	  # Delete a snapshot named snap1
+	  pi delete snapshot snap1
+
+	  # Delete multiple snapshots
+	  pi delete snapshots snap1 snap2`))
+)
+
+// DeleteSnapshotGeneric is the implementation of the delete snapshot command
+func (o *DeleteOptions) DeleteSnapshotGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) != 0 && o.DeleteAll {
+		return fmt.Errorf("name cannot be provided when --all is specified")
+	}
+	if len(args) == 0 && !o.DeleteAll {
+		return fmt.Errorf("resource(s) were provided, but no name or --all flag specified")
+	}
+
+	if cfg, err := f.ClientConfig(); err != nil {
+		return err
+	} else {
+		cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+
+		if o.DeleteAll {
+			snapList, err := cli.Client.SnapshotList(ctx, filters.NewArgs())
+			if err != nil {
+				return fmt.Errorf("failed to list all snapshots, error:%v", err)
+			}
+			for _, snap := range snapList.Snapshots {
+				args = append(args, snap.Name)
+			}
+		}
+
+		for _, name := range args {
+			if err := cli.Client.SnapshotRemove(ctx, name); err != nil {
+				fmt.Println(err)
+			} else {
+				fmt.Printf("snapshot \"%v\" deleted\n", name)
+			}
+		}
+	}
+	return nil
+}
@@ -20,13 +20,13 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/pi/pkg/hyper"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
 
 	"github.com/spf13/cobra"
-	"net/http"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 )
 
 // NewCmdDeleteVolume groups subcommands to delete various zones of volumes
@@ -70,11 +70,13 @@ func (o *DeleteOptions) DeleteVolumeGeneric(f cmdutil.Factory, cmdOut io.Writer,
 	if cfg, err := f.ClientConfig(); err != nil {
 		return err
 	} else {
-		hyperConn := hyper.NewHyperConn(cfg)
-		volCli := hyper.NewVolumeCli(hyperConn)
+		conn, err := hyper.NewConn(cfg)
+		if err != nil {
+			return err
+		}
 
 		if o.DeleteAll {
-			_, volList, err := volCli.ListVolumes("")
+			volList, err := conn.ListVolumes("")
 			if err != nil {
 				return fmt.Errorf("failed to list all fips, error:%v", err)
 			}
@@ -83,14 +85,14 @@ func (o *DeleteOptions) DeleteVolumeGeneric(f cmdutil.Factory, cmdOut io.Writer,
 			}
 		}
 
+		errs := []error{}
 		for _, name := range args {
-			httpStatus, result := volCli.DeleteVolume(name, "")
-			if httpStatus == http.StatusNoContent {
-				fmt.Printf("volume \"%v\" deleted\n", name)
+			if err := conn.DeleteVolume(name, ""); err != nil {
+				errs = append(errs, fmt.Errorf("failed to delete volume %q, error:%v", name, err))
 			} else {
-				fmt.Println(result)
+				fmt.Printf("volume \"%v\" deleted\n", name)
 			}
 		}
+		return utilerrors.NewAggregate(errs)
 	}
-	return nil
 }
@@ -0,0 +1,363 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/hyperhq/pi/pkg/pi"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/cmd/util/editor"
+	"github.com/hyperhq/pi/pkg/pi/resource"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+	"github.com/hyperhq/pi/pkg/pi/validation"
+
+	"github.com/evanphx/json-patch"
+	"github.com/ghodss/yaml"
+	"github.com/hyperhq/client-go/kubernetes/scheme"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+var (
+	editLong = templates.LongDesc(i18n.T(`
+		Edit a resource(pod, job, service, secret) from the default editor.
+
+		The edit command opens the live object in the editor defined by the KUBE_EDITOR or
+		EDITOR environment variables, or vi. The object is shown as YAML, or as JSON with
+		-o json. When the file is saved and the editor closed, the object is validated and
+		sent to the server as a patch of the changed fields, or replaces the object with
+		--replace.
+
+		If the object is invalid or the server rejects the change, the file is reopened
+		with the errors as a comment at the top. Saving the file unchanged, or empty,
+		cancels the edit.
+
+		The values of a secret are base64 encoded, --decode shows them decoded in the
+		stringData field instead. Values which are not valid UTF-8 are left in data.
+
+		Most fields of a pod can not be changed once it is created, see 'pi explain pods.spec'.`))
+
+	editExample = templates.Examples(i18n.T(`
+		# Edit the service named 'my-lbs'
+		pi edit service/my-lbs
+
+		# Edit the values of the secret 'db' decoded
+		pi edit secret/db --decode
+
+		# Edit the job 'report' in JSON using nano
+		KUBE_EDITOR="nano" pi edit job/report -o json`))
+)
+
+// editHeader is the comment at the top of the edited file
+const editHeader = `Please edit the object below. The lines beginning with a '#' at the top will be
+ignored, and an empty file will abort the edit. If an error occurs while saving this file will be
+reopened with the relevant failures.
+`
+
+// EditOptions declare the arguments accepted by the Edit command
+type EditOptions struct {
+	resource.FilenameOptions
+
+	Output  string
+	Decode  bool
+	Replace bool
+
+	f      cmdutil.Factory
+	editor editor.Editor
+	schema validation.Schema
+	Args   []string
+	Out    io.Writer
+	ErrOut io.Writer
+}
+
+// NewCmdEdit creates a command to edit live objects in the editor of the user
+func NewCmdEdit(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
+	options := &EditOptions{
+		Output: "yaml",
+		Out:    out,
+		ErrOut: errOut,
+	}
+	cmd := &cobra.Command{
+		Use:     "edit (RESOURCE/NAME | -f FILENAME)",
+		Short:   i18n.T("Edit a resource on the server"),
+		Long:    editLong,
+		Example: editExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	cmdutil.AddFilenameOptionFlags(cmd, &options.FilenameOptions, "identifying the resource to edit.")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format of the edited object. One of: yaml|json")
+	cmd.Flags().BoolVar(&options.Decode, "decode", options.Decode, "Edit the values of secrets decoded, in stringData, instead of base64 encoded")
+	cmd.Flags().BoolVar(&options.Replace, "replace", options.Replace, "Replace the object with the edited one instead of patching the changed fields")
+	return cmd
+}
+
+// Complete checks the objects to edit are given, and sets up the editor
+func (o *EditOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && cmdutil.IsFilenameSliceEmpty(o.Filenames) {
+		return cmdutil.UsageErrorf(cmd, "Required resource not specified.")
+	}
+	if o.Output != "yaml" && o.Output != "json" {
+		return cmdutil.UsageErrorf(cmd, "The flag 'output' must be one of yaml|json")
+	}
+	validator, err := f.Validator(true)
+	if err != nil {
+		return err
+	}
+	o.schema = validation.ConjunctiveSchema{validator, validation.PlatformSchema{}}
+	o.editor = editor.NewDefaultEditor(f.EditorEnvs())
+	o.f = f
+	o.Args = args
+	return nil
+}
+
+// Run edits the objects one after the other.
+func (o *EditOptions) Run() error {
+	cmdNamespace, enforceNamespace, err := o.f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	r := o.f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(cmdNamespace).DefaultNamespace().
+		FilenameParam(enforceNamespace, &o.FilenameOptions).
+		ResourceTypeOrNameArgs(true, o.Args...).
+		Latest().
+		Flatten().
+		Do()
+	infos, err := r.Infos()
+	if err != nil {
+		return err
+	}
+	mapper := r.Mapper().RESTMapper
+
+	errs := []error{}
+	for _, info := range infos {
+		edited, err := o.edit(info)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if edited {
+			o.f.PrintSuccess(mapper, false, o.Out, info.Mapping.Resource, info.Name, false, "edited")
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// edit opens the object in the editor until the edited object is saved on the
+// server, or the edit is cancelled. It returns false if the edit is cancelled.
+func (o *EditOptions) edit(info *resource.Info) (bool, error) {
+	original, err := runtime.Encode(unstructured.UnstructuredJSONScheme, info.Object)
+	if err != nil {
+		return false, err
+	}
+	decode := o.Decode && info.Mapping.GroupVersionKind.Kind == "Secret"
+	content, err := o.editableContent(original, decode)
+	if err != nil {
+		return false, err
+	}
+
+	var editErr error
+	var previous []byte
+	for {
+		buf := &bytes.Buffer{}
+		writeEditHeader(buf, info, editErr)
+		buf.Write(content)
+
+		saved, file, err := o.editor.LaunchTempFile(fmt.Sprintf("%s-edit-", info.Mapping.Resource), "."+o.Output, buf)
+		if err != nil {
+			return false, preservedFile(err, file)
+		}
+
+		content = editor.StripComments(saved)
+		if len(bytes.TrimSpace(content)) == 0 {
+			os.Remove(file)
+			fmt.Fprintln(o.ErrOut, "Edit cancelled, saved file was empty.")
+			return false, nil
+		}
+		if editErr != nil && bytes.Equal(content, previous) {
+			return false, preservedFile(fmt.Errorf("Edit cancelled, no valid changes were saved"), file)
+		}
+		previous = content
+
+		modified, err := yaml.YAMLToJSON(content)
+		if err == nil && decode {
+			modified, err = editor.EncodeSecretData(modified)
+		}
+		if err != nil {
+			editErr = err
+			os.Remove(file)
+			continue
+		}
+		if equal, err := equalJSON(original, modified); err == nil && equal {
+			os.Remove(file)
+			fmt.Fprintln(o.ErrOut, "Edit cancelled, no changes made.")
+			return false, nil
+		}
+		if err := o.save(info, original, modified); err != nil {
+			editErr = err
+			os.Remove(file)
+			continue
+		}
+		os.Remove(file)
+		return true, nil
+	}
+}
+
+// editableContent returns the object as edited, with the values of a secret
+// decoded in stringData if decode is set
+func (o *EditOptions) editableContent(original []byte, decode bool) ([]byte, error) {
+	data := original
+	if decode {
+		var err error
+		if data, err = editor.DecodeSecretData(original); err != nil {
+			return nil, err
+		}
+	}
+	if o.Output == "json" {
+		buf := &bytes.Buffer{}
+		if err := json.Indent(buf, data, "", "    "); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\n")
+		return buf.Bytes(), nil
+	}
+	return yaml.JSONToYAML(data)
+}
+
+// save validates the modified object and patches or replaces the object with it
+func (o *EditOptions) save(info *resource.Info, original, modified []byte) error {
+	obj, err := runtime.Decode(unstructured.UnstructuredJSONScheme, modified)
+	if err != nil {
+		return err
+	}
+	edited, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected object %T", obj)
+	}
+	gvk := info.Mapping.GroupVersionKind
+	if edited.GroupVersionKind() != gvk {
+		return fmt.Errorf("the kind and apiVersion can not be changed, expected %s %s", gvk.GroupVersion().String(), gvk.Kind)
+	}
+	if edited.GetName() != info.Name {
+		return fmt.Errorf("the name can not be changed, expected %q", info.Name)
+	}
+
+	// keep the configuration recorded by pi apply in line with the edited object
+	editedInfo := *info
+	editedInfo.Object = edited
+	if err := pi.UpdateApplyAnnotation(&editedInfo, unstructured.UnstructuredJSONScheme); err != nil {
+		return err
+	}
+	if modified, err = runtime.Encode(unstructured.UnstructuredJSONScheme, edited); err != nil {
+		return err
+	}
+	if err := o.schema.ValidateBytes(modified); err != nil {
+		return err
+	}
+
+	helper := resource.NewHelper(info.Client, info.Mapping)
+	if o.Replace {
+		updated, err := helper.Replace(info.Namespace, info.Name, false, edited)
+		if err != nil {
+			return err
+		}
+		return info.Refresh(updated, true)
+	}
+	patch, patchType, err := twoWayMergePatch(info, original, modified)
+	if err != nil {
+		return err
+	}
+	updated, err := helper.Patch(info.Namespace, info.Name, patchType, patch)
+	if err != nil {
+		return err
+	}
+	return info.Refresh(updated, true)
+}
+
+// twoWayMergePatch returns the patch turning the original object into the modified
+// one, a strategic merge patch for the typed kinds or a merge patch otherwise
+func twoWayMergePatch(info *resource.Info, original, modified []byte) ([]byte, types.PatchType, error) {
+	versionedObject, err := scheme.Scheme.New(info.Mapping.GroupVersionKind)
+	if err != nil {
+		patch, err := jsonpatch.CreateMergePatch(original, modified)
+		return patch, types.MergePatchType, err
+	}
+	patch, err := strategicpatch.CreateTwoWayMergePatch(original, modified, versionedObject)
+	return patch, types.StrategicMergePatchType, err
+}
+
+// writeEditHeader writes the header comment of the edited file, with the error of
+// the previous save if any
+func writeEditHeader(w io.Writer, info *resource.Info, editErr error) {
+	for _, line := range strings.Split(strings.TrimSuffix(editHeader, "\n"), "\n") {
+		fmt.Fprintf(w, "# %s\n", line)
+	}
+	if editErr != nil {
+		fmt.Fprintf(w, "#\n# %s %q was not valid:\n", info.Mapping.Resource, info.Name)
+		errs := []error{editErr}
+		if agg, ok := editErr.(utilerrors.Aggregate); ok {
+			errs = utilerrors.Flatten(agg).Errors()
+		}
+		for _, err := range errs {
+			for i, line := range strings.Split(err.Error(), "\n") {
+				if i == 0 {
+					fmt.Fprintf(w, "# * %s\n", line)
+				} else {
+					fmt.Fprintf(w, "#   %s\n", line)
+				}
+			}
+		}
+	}
+	fmt.Fprintln(w, "#")
+}
+
+func preservedFile(err error, path string) error {
+	if len(path) > 0 {
+		if _, statErr := os.Stat(path); statErr == nil {
+			return fmt.Errorf("%v\nA copy of your changes has been stored to %q", err, path)
+		}
+	}
+	return err
+}
+
+func equalJSON(a, b []byte) (bool, error) {
+	var objA, objB interface{}
+	if err := json.Unmarshal(a, &objA); err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal(b, &objB); err != nil {
+		return false, err
+	}
+	return reflect.DeepEqual(objA, objB), nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	restclient "github.com/hyperhq/client-go/rest"
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/hyper-api/types/filters"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+)
+
+var (
+	eventsLong = templates.LongDesc(i18n.T(`
+		Stream the lifecycle events of the pods, volumes, fips and services of the tenant.
+
+		Events are printed as they happen, until interrupted or until the time given with --until.
+		With -o json every event is printed as a JSON object on its own line.`))
+
+	eventsExample = templates.Examples(i18n.T(`
+		# Stream all events
+		pi events
+
+		# Stream the events of pods and volumes of the last 10 minutes, and the new ones
+		pi events --type=pod --type=volume --since=10m
+
+		# Stream the events of the objects labeled app=wordpress as JSON
+		pi events -l app=wordpress -o json
+
+		# Print the events of the last hour, then exit
+		pi events --since=1h --until=0s`))
+)
+
+// EventsOptions declare the arguments accepted by the Events command
+type EventsOptions struct {
+	Types    []string
+	Since    string
+	Until    string
+	Selector string
+	Output   string
+
+	Config *restclient.Config
+
+	Out io.Writer
+	Err io.Writer
+}
+
+// eventMessage is an event of the event stream
+type eventMessage struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	Time     int64 `json:"time,omitempty"`
+	TimeNano int64 `json:"timeNano,omitempty"`
+}
+
+// NewCmdEvents creates a command to stream events
+func NewCmdEvents(f cmdutil.Factory, cmdOut, cmdErr io.Writer) *cobra.Command {
+	options := &EventsOptions{
+		Out: cmdOut,
+		Err: cmdErr,
+	}
+	cmd := &cobra.Command{
+		Use:     "events [--type=pod] [--since=10m] [-l key=value] [-o json]",
+		Short:   i18n.T("Stream the events of the tenant"),
+		Long:    eventsLong,
+		Example: eventsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	cmd.Flags().StringSliceVar(&options.Types, "type", options.Types, "Only show the events of this type of object, one of: pod, volume, fip, service. Can be repeated")
+	cmd.Flags().StringVar(&options.Since, "since", options.Since, "Show the events created since a timestamp (e.g. 2013-01-02T13:23:37) or relative (e.g. 42m for 42 minutes)")
+	cmd.Flags().StringVar(&options.Until, "until", options.Until, "Stop streaming at a timestamp (e.g. 2013-01-02T13:23:37) or relative (e.g. 42m for 42 minutes)")
+	cmd.Flags().StringVarP(&options.Selector, "selector", "l", options.Selector, "Label selector to filter on, supports '=' only (e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format. One of: json")
+	return cmd
+}
+
+// Complete completes all the required options for events.
+func (o *EventsOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "unexpected args: %v", args)
+	}
+	if o.Output != "" && o.Output != "json" {
+		return cmdutil.UsageErrorf(cmd, "output format %q not recognized, one of: json", o.Output)
+	}
+
+	config, err := f.ClientConfig()
+	if err != nil {
+		return err
+	}
+	o.Config = config
+	return nil
+}
+
+// filters returns the server side filters of the event stream
+func (o *EventsOptions) filters() (filters.Args, error) {
+	args := filters.NewArgs()
+	for _, t := range o.Types {
+		args.Add("type", t)
+	}
+	if len(o.Selector) > 0 {
+		for _, requirement := range strings.Split(o.Selector, ",") {
+			if strings.Contains(requirement, "!=") || !strings.Contains(requirement, "=") {
+				return args, fmt.Errorf("invalid label selector %q, supports '=' only", o.Selector)
+			}
+			args.Add("label", strings.TrimSpace(strings.Replace(requirement, "==", "=", 1)))
+		}
+	}
+	return args, nil
+}
+
+// Run streams the events until the stream is closed.
+func (o *EventsOptions) Run() error {
+	eventFilters, err := o.filters()
+	if err != nil {
+		return err
+	}
+	cli, err := hyper.NewHyperCli(o.Config.Host, o.Config, nil, o.Out, o.Err)
+	if err != nil {
+		return err
+	}
+
+	body, err := cli.Client.Events(context.Background(), types.EventsOptions{
+		Since:   o.Since,
+		Until:   o.Until,
+		Filters: eventFilters,
+	})
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	decoder := json.NewDecoder(body)
+	for {
+		var event eventMessage
+		if err := decoder.Decode(&event); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if err := o.printEvent(event); err != nil {
+			return err
+		}
+	}
+}
+
+// printEvent prints an event on a single line, e.g.
+// 2018-05-02T01:00:03+00:00 pod start nginx (app=nginx)
+func (o *EventsOptions) printEvent(event eventMessage) error {
+	if o.Output == "json" {
+		buf, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, string(buf))
+		return nil
+	}
+
+	timestamp := time.Unix(event.Time, 0)
+	if event.TimeNano != 0 {
+		timestamp = time.Unix(0, event.TimeNano)
+	}
+	line := fmt.Sprintf("%s %s %s %s", timestamp.Format("2006-01-02T15:04:05-07:00"), event.Type, event.Action, event.Actor.ID)
+	if len(event.Actor.Attributes) > 0 {
+		attributes := []string{}
+		for k, v := range event.Actor.Attributes {
+			attributes = append(attributes, k+"="+v)
+		}
+		sort.Strings(attributes)
+		line += " (" + strings.Join(attributes, ", ") + ")"
+	}
+	fmt.Fprintln(o.Out, line)
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2014 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/explain"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	explainLong = templates.LongDesc(`
+		List the fields for supported resources
+
+		This command describes the fields associated with each resource supported by Pi.
+		Fields are identified via a simple JSONPath identifier:
+
+			<type>.<fieldName>[.<fieldName>]
+
+		Add the --recursive flag to display all of the fields at once without descriptions.
+		The documentation is built into pi, so it is available without contacting the server.
+		Fields that Pi does not support are left out, and Pi specific behaviour, such as the
+		sh_hyper_instancetype annotation and the zone node selector, is described in place.`)
+
+	explainExamples = templates.Examples(i18n.T(`
+		# Get the documentation of the resource and its fields
+		pi explain pods
+
+		# Get the documentation of a specific field of a resource
+		pi explain pods.spec.containers
+
+		# Get the documentation of the annotations recognized by Pi
+		pi explain pods.metadata.annotations
+
+		# Get all the fields of a volume source
+		pi explain pod.spec.volumes.flexVolume --recursive`))
+)
+
+// NewCmdExplain returns a cobra command for swagger docs
+func NewCmdExplain(f cmdutil.Factory, out, cmdErr io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "explain RESOURCE",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Documentation of resources"),
+		Long:                  explainLong + "\n\n" + cmdutil.ValidExplainResourceTypeList(f),
+		Example:               explainExamples,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := RunExplain(f, out, cmdErr, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().Bool("recursive", false, "Print the fields of fields (Currently only 1 level deep)")
+	cmd.Flags().String("api-version", "", "Get different explanations for particular API group/version")
+	return cmd
+}
+
+// RunExplain executes the appropriate steps to print a model's documentation
+func RunExplain(f cmdutil.Factory, out, cmdErr io.Writer, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintf(cmdErr, "You must specify the type of resource to explain. %s\n\n", cmdutil.ValidExplainResourceTypeList(f))
+		return cmdutil.UsageErrorf(cmd, "Required resource not specified.")
+	}
+	if len(args) > 1 {
+		return cmdutil.UsageErrorf(cmd, "We accept only this format: explain RESOURCE")
+	}
+
+	recursive := cmdutil.GetFlagBool(cmd, "recursive")
+	apiVersionString := cmdutil.GetFlagString(cmd, "api-version")
+
+	mapper, _ := f.Object()
+	inModel, fieldsPath, err := explain.SplitAndParseResourceRequest(args[0], mapper)
+	if err != nil {
+		return err
+	}
+
+	fullySpecifiedGVR, groupResource := schema.ParseResourceArg(inModel)
+	gvk := schema.GroupVersionKind{}
+	if fullySpecifiedGVR != nil {
+		gvk, _ = mapper.KindFor(*fullySpecifiedGVR)
+	}
+	if gvk.Empty() {
+		gvk, err = mapper.KindFor(groupResource.WithVersion(""))
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(apiVersionString) != 0 {
+		apiVersion, err := schema.ParseGroupVersion(apiVersionString)
+		if err != nil {
+			return err
+		}
+		gvk = apiVersion.WithKind(gvk.Kind)
+	}
+
+	resources, err := f.OpenAPISchema()
+	if err != nil {
+		return err
+	}
+
+	schema := resources.LookupResource(gvk)
+	if schema == nil {
+		return fmt.Errorf("Couldn't find resource for %q", gvk)
+	}
+
+	return explain.PrintModelDescription(fieldsPath, out, schema, recursive)
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+
+	hyperclient "github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/pi/pkg/hyper"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+)
+
+// fipClient calls the fip endpoints of the Hyper API through a hyper.Conn, so that
+// the other objects of a file are still handled when a call fails
+type fipClient struct {
+	conn *hyper.Conn
+}
+
+func newFipClient(f cmdutil.Factory) (*fipClient, error) {
+	cfg, err := f.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := hyper.NewConn(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &fipClient{conn: conn}, nil
+}
+
+// list returns the fips of the tenant
+func (c *fipClient) list() ([]hyperclient.FipResponse, error) {
+	fips, err := c.conn.ListFips()
+	if err != nil {
+		return nil, fmt.Errorf("error listing fips: %v", err)
+	}
+	return fips, nil
+}
+
+// find returns the fip named name, or nil if there is none
+func (c *fipClient) find(name string) (*hyperclient.FipResponse, error) {
+	fips, err := c.list()
+	if err != nil {
+		return nil, err
+	}
+	for i := range fips {
+		if fips[i].Name == name {
+			return &fips[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// allocate allocates a new fip
+func (c *fipClient) allocate() (*hyperclient.FipResponse, error) {
+	fips, err := c.conn.AllocateFips(1)
+	if err != nil {
+		return nil, fmt.Errorf("error allocating fip: %v", err)
+	}
+	if len(fips) == 0 {
+		return nil, fmt.Errorf("no fip allocated")
+	}
+	return &fips[0], nil
+}
+
+func (c *fipClient) name(ip, name string) error {
+	if err := c.conn.NameFip(ip, name); err != nil {
+		return fmt.Errorf("error naming fip %s: %v", ip, err)
+	}
+	return nil
+}
+
+func (c *fipClient) release(ip string) error {
+	if err := c.conn.ReleaseFip(ip); err != nil {
+		return fmt.Errorf("error releasing fip %s: %v", ip, err)
+	}
+	return nil
+}
@@ -23,8 +23,8 @@ import (
 	"strings"
 	"time"
 
-	"github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
 	"github.com/hyperhq/pi"
+	"github.com/hyperhq/pi/pkg/hyper"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
@@ -62,9 +62,11 @@ func InfoGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args [
 	if cfg, err := f.ClientConfig(); err != nil {
 		return err
 	} else {
-		hyperConn := hyper.NewHyperConn(cfg)
-		infoCli := hyper.NewInfoCli(hyperConn)
-		if _, info, err := infoCli.GetInfo(); err != nil {
+		conn, err := hyper.NewConn(cfg)
+		if err != nil {
+			return err
+		}
+		if info, err := conn.GetInfo(); err != nil {
 			return err
 		} else {
 			PrintInfoResult(info)
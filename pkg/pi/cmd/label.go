@@ -0,0 +1,374 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/resource"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/evanphx/json-patch"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+var (
+	labelLong = templates.LongDesc(i18n.T(`
+		Update the labels on a resource(pod, job, service, secret).
+
+		* A label key and value must begin with a letter or number, and may contain letters, numbers, hyphens, dots, and underscores, up to 63 characters each.
+		* Optionally, the key can begin with a DNS subdomain prefix and a single '/', like example.com/my-app
+		* If --overwrite is true, then existing labels can be overwritten, otherwise attempting to overwrite a label will result in an error.
+		* If --resource-version is specified, then updates will use this resource version, otherwise the existing resource-version will be used.
+
+		The pods selected by a service are the pods matching its selector, changing the labels of
+		pods re-routes a service to them without recreating them.`))
+
+	labelExample = templates.Examples(i18n.T(`
+		# Update pod 'web' with the label 'role' and the value 'canary', overwriting any existing value.
+		pi label pods web role=canary --overwrite
+
+		# Update all pods labeled app=nginx with the label 'zone' and the value 'a'.
+		pi label pods -l app=nginx zone=a
+
+		# Update all pods with the label 'status' and the value 'unhealthy'.
+		pi label pods --all status=unhealthy
+
+		# Update a pod identified by the type and name in "pod.json"
+		pi label -f pod.json status=unhealthy
+
+		# Update pod 'web' only if the resource is unchanged from version 1.
+		pi label pods web status=unhealthy --resource-version=1
+
+		# Remove the label named 'role' from pod 'web', and print the pod as it would be without changing it.
+		pi label pods web role- --dry-run -o yaml`))
+)
+
+// MetadataMapOptions declare the arguments accepted by the Label and Annotate
+// commands, which update the labels or the annotations of resources
+type MetadataMapOptions struct {
+	resource.FilenameOptions
+
+	Selector        string
+	All             bool
+	Overwrite       bool
+	ResourceVersion string
+	DryRun          bool
+	Output          string
+
+	// field is the field of the metadata updated, labels or annotations
+	field string
+	// verb is the name of the command
+	verb string
+	// operation is printed for every updated resource
+	operation string
+	// validate checks the new key and value pairs
+	validate func(map[string]string) error
+	// print prints an updated resource with -o json or yaml
+	print func(*resource.Info) error
+
+	resources  []string
+	newPairs   map[string]string
+	removeKeys []string
+
+	f   cmdutil.Factory
+	Out io.Writer
+}
+
+// NewCmdLabel creates a command to update the labels of resources
+func NewCmdLabel(f cmdutil.Factory, out io.Writer) *cobra.Command {
+	options := &MetadataMapOptions{
+		field:     "labels",
+		verb:      "label",
+		operation: "labeled",
+		validate:  validateLabels,
+		Out:       out,
+	}
+	cmd := &cobra.Command{
+		Use:     "label [--overwrite] (-f FILENAME | TYPE NAME) KEY_1=VAL_1 ... KEY_N=VAL_N [--resource-version=version]",
+		Short:   i18n.T("Update the labels on a resource"),
+		Long:    labelLong,
+		Example: labelExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	addMetadataMapFlags(cmd, options, "label")
+	return cmd
+}
+
+func addMetadataMapFlags(cmd *cobra.Command, options *MetadataMapOptions, noun string) {
+	cmdutil.AddFilenameOptionFlags(cmd, &options.FilenameOptions, fmt.Sprintf("identifying the resource to update the %s", options.field))
+	cmd.Flags().StringVarP(&options.Selector, "selector", "l", options.Selector, "Selector (label query) to filter on, not including uninitialized ones, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2).")
+	cmd.Flags().BoolVar(&options.All, "all", options.All, "Select all resources, in the namespace of the specified resource types")
+	cmd.Flags().BoolVar(&options.Overwrite, "overwrite", options.Overwrite, fmt.Sprintf("If true, allow %ss to be overwritten, otherwise reject %s updates that overwrite existing %ss.", noun, noun, noun))
+	cmd.Flags().StringVar(&options.ResourceVersion, "resource-version", options.ResourceVersion, i18n.T("If non-empty, the update will only succeed if this is the current resource-version for the object. Only valid when specifying a single resource."))
+	cmdutil.AddDryRunFlag(cmd)
+	cmdutil.AddPrinterFlags(cmd)
+}
+
+// Complete splits the args into the resources and the key value pairs
+func (o *MetadataMapOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	resources, pairs, err := splitResourcesAndPairs(args)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, err.Error())
+	}
+	if len(resources) < 1 && cmdutil.IsFilenameSliceEmpty(o.Filenames) {
+		return cmdutil.UsageErrorf(cmd, "one or more resources must be specified as <resource> <name> or <resource>/<name>")
+	}
+	if len(pairs) < 1 {
+		return cmdutil.UsageErrorf(cmd, "at least one %s update is required", strings.TrimSuffix(o.field, "s"))
+	}
+	if o.All && len(o.Selector) > 0 {
+		return cmdutil.UsageErrorf(cmd, "cannot set --all and --selector at the same time")
+	}
+	o.DryRun = cmdutil.GetDryRunFlag(cmd)
+	o.Output = cmdutil.GetFlagString(cmd, "output")
+	switch o.Output {
+	case "", "name", "json", "yaml":
+	default:
+		return cmdutil.UsageErrorf(cmd, "Unexpected -o output mode: %v, allowed modes are: json,yaml,name", o.Output)
+	}
+
+	newPairs, removeKeys, err := parseMetadataPairs(pairs, o.field)
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, err.Error())
+	}
+	if err := o.validate(newPairs); err != nil {
+		return cmdutil.UsageErrorf(cmd, err.Error())
+	}
+	o.resources, o.newPairs, o.removeKeys = resources, newPairs, removeKeys
+	o.f = f
+	o.print = func(info *resource.Info) error {
+		return f.PrintResourceInfoForCommand(cmd, info, o.Out)
+	}
+	return nil
+}
+
+// Run updates the labels or annotations of every selected resource.
+func (o *MetadataMapOptions) Run() error {
+	cmdNamespace, enforceNamespace, err := o.f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	r := o.f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(cmdNamespace).DefaultNamespace().
+		FilenameParam(enforceNamespace, &o.FilenameOptions).
+		LabelSelectorParam(o.Selector).
+		SelectAllParam(o.All).
+		ResourceTypeOrNameArgs(o.All, o.resources...).
+		Flatten().
+		Latest().
+		Do()
+	if err := r.Err(); err != nil {
+		return err
+	}
+	mapper := r.Mapper().RESTMapper
+
+	infos, err := r.Infos()
+	if err != nil {
+		return err
+	}
+	if len(infos) == 0 {
+		return fmt.Errorf("no objects passed to %s", o.verb)
+	}
+	if len(o.ResourceVersion) > 0 && len(infos) > 1 {
+		return fmt.Errorf("--resource-version may only be used with a single resource")
+	}
+
+	errs := []error{}
+	for _, info := range infos {
+		if err := o.update(info); err != nil {
+			errs = append(errs, cmdutil.AddSourceToErr("updating", info.Source, err))
+			continue
+		}
+		if len(o.Output) > 0 && o.Output != "name" {
+			if err := o.print(info); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		o.f.PrintSuccess(mapper, o.Output == "name", o.Out, info.Mapping.Resource, info.Name, o.DryRun, o.operation)
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// update patches the labels or annotations of the object of info
+func (o *MetadataMapOptions) update(info *resource.Info) error {
+	obj, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected object %T", info.Object)
+	}
+	original, err := runtime.Encode(unstructured.UnstructuredJSONScheme, obj)
+	if err != nil {
+		return err
+	}
+
+	current, _ := unstructured.NestedStringMap(obj.Object, "metadata", o.field)
+	updated, err := updateMetadataMap(current, o.newPairs, o.removeKeys, o.Overwrite)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(current, updated) || (len(current) == 0 && len(updated) == 0) {
+		return nil
+	}
+	modifiedObj := obj.DeepCopy()
+	if len(updated) == 0 {
+		unstructured.RemoveNestedField(modifiedObj.Object, "metadata", o.field)
+	} else {
+		unstructured.SetNestedStringMap(modifiedObj.Object, updated, "metadata", o.field)
+	}
+	if o.DryRun {
+		info.Refresh(modifiedObj, true)
+		return nil
+	}
+
+	modified, err := runtime.Encode(unstructured.UnstructuredJSONScheme, modifiedObj)
+	if err != nil {
+		return err
+	}
+	patch, err := jsonpatch.CreateMergePatch(original, modified)
+	if err != nil {
+		return err
+	}
+	if len(o.ResourceVersion) > 0 {
+		// the server rejects the patch if the object changed since that version
+		if patch, err = setPatchResourceVersion(patch, o.ResourceVersion); err != nil {
+			return err
+		}
+	}
+	patched, err := resource.NewHelper(info.Client, info.Mapping).Patch(info.Namespace, info.Name, types.MergePatchType, patch)
+	if err != nil {
+		return err
+	}
+	return info.Refresh(patched, true)
+}
+
+func setPatchResourceVersion(patch []byte, resourceVersion string) ([]byte, error) {
+	patchMap := map[string]interface{}{}
+	if err := json.Unmarshal(patch, &patchMap); err != nil {
+		return nil, err
+	}
+	metadata, _ := patchMap["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		patchMap["metadata"] = metadata
+	}
+	metadata["resourceVersion"] = resourceVersion
+	return json.Marshal(patchMap)
+}
+
+// splitResourcesAndPairs splits the args into the resources, followed by the
+// KEY=VALUE and KEY- pairs
+func splitResourcesAndPairs(args []string) ([]string, []string, error) {
+	resources, pairs := []string{}, []string{}
+	for _, arg := range args {
+		isPair := (strings.Contains(arg, "=") && arg[0] != '=') || (strings.HasSuffix(arg, "-") && arg != "-")
+		switch {
+		case isPair:
+			pairs = append(pairs, arg)
+		case len(pairs) == 0:
+			resources = append(resources, arg)
+		default:
+			return nil, nil, fmt.Errorf("all resources must be specified before the changes: %s", arg)
+		}
+	}
+	return resources, pairs, nil
+}
+
+// parseMetadataPairs parses the KEY=VALUE pairs to set and the KEY- keys to remove
+func parseMetadataPairs(pairs []string, field string) (map[string]string, []string, error) {
+	newPairs := map[string]string{}
+	removeKeys := []string{}
+	invalid := []string{}
+	for _, pair := range pairs {
+		switch {
+		case strings.Contains(pair, "="):
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts[0]) == 0 {
+				invalid = append(invalid, pair)
+				continue
+			}
+			newPairs[parts[0]] = parts[1]
+		case strings.HasSuffix(pair, "-") && len(pair) > 1:
+			removeKeys = append(removeKeys, strings.TrimSuffix(pair, "-"))
+		default:
+			invalid = append(invalid, pair)
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, nil, fmt.Errorf("invalid %s format: %s, expected KEY=VALUE or KEY-", field, strings.Join(invalid, ", "))
+	}
+	for _, key := range removeKeys {
+		if _, found := newPairs[key]; found {
+			return nil, nil, fmt.Errorf("can not both modify and remove %s %q in the same command", strings.TrimSuffix(field, "s"), key)
+		}
+	}
+	return newPairs, removeKeys, nil
+}
+
+// updateMetadataMap returns the map with the new pairs set and the keys removed.
+// An existing key is only set to another value if overwrite is set.
+func updateMetadataMap(current, newPairs map[string]string, removeKeys []string, overwrite bool) (map[string]string, error) {
+	updated := map[string]string{}
+	for key, value := range current {
+		updated[key] = value
+	}
+	keys := []string{}
+	for key := range newPairs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if value, found := updated[key]; found && value != newPairs[key] && !overwrite {
+			return nil, fmt.Errorf("'%s' already has a value (%s), and --overwrite is false", key, value)
+		}
+		updated[key] = newPairs[key]
+	}
+	for _, key := range removeKeys {
+		delete(updated, key)
+	}
+	return updated, nil
+}
+
+func validateLabels(labels map[string]string) error {
+	var errs []error
+	for key, value := range labels {
+		for _, msg := range validation.IsQualifiedName(key) {
+			errs = append(errs, fmt.Errorf("invalid label key %q: %s", key, msg))
+		}
+		for _, msg := range validation.IsValidLabelValue(value) {
+			errs = append(errs, fmt.Errorf("invalid label value %q: %s", value, msg))
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,346 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/resource"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+	"github.com/hyperhq/pi/pkg/pi/validation"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var (
+	lintLong = templates.LongDesc(i18n.T(`
+		Check manifests against the schema and the rules of the Pi platform, without
+		creating anything.
+
+		Besides the schema of the objects, the following rules are checked:
+
+		* kind: the kind is served by Pi
+		* volume-type: the volumes are emptyDir, secret, gitRepo or flexVolume volumes
+		* volume-count: a pod uses at most 4 Pi volumes
+		* volume-mount: the volume mounts refer to volumes of the pod
+		* volume-ref: a flexVolume names a Pi volume that exists
+		* volume-zone: the Pi volumes are in the zone of the pod
+		* instance-type: the sh_hyper_instancetype annotation is a known size
+		* memory-limit: the memory limits of the containers fit in the largest instance type
+		* secret-ref: the image pull secrets and the secrets used by the pod exist
+
+		The volumes and secrets of the manifests are taken into account. With --remote, the
+		volumes and secrets of the server are listed too, and a reference to any other volume
+		or secret is a problem.
+
+		The command exits with an error if a problem is found.`))
+
+	lintExample = templates.Examples(i18n.T(`
+		# Check a pod manifest
+		pi lint -f examples/pod/pod-volume-unsupported.yaml
+
+		# Check the manifests of a directory against the volumes and secrets of the server
+		pi lint -f examples/wordpress --remote
+
+		# Check a stack template, and print the problems as json
+		pi lint -f stack.tpl.yaml --param-file=prod.env -o json`))
+)
+
+// lintSyntaxRule and lintSchemaRule are the rules of the problems found decoding a
+// document and validating it against the schema
+const (
+	lintSyntaxRule = "syntax"
+	lintSchemaRule = "schema"
+)
+
+// LintOptions declare the arguments accepted by the Lint command
+type LintOptions struct {
+	resource.FilenameOptions
+
+	Remote bool
+	Output string
+
+	f   cmdutil.Factory
+	Out io.Writer
+}
+
+// lintDocument is an object read from a manifest
+type lintDocument struct {
+	File     string
+	Document int
+	Kind     string
+	Name     string
+	Raw      []byte
+}
+
+// lintProblem is a problem found in a document, printed as json by -o json
+type lintProblem struct {
+	File     string `json:"file"`
+	Document int    `json:"document"`
+	Kind     string `json:"kind,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Rule     string `json:"rule"`
+	Field    string `json:"field,omitempty"`
+	Message  string `json:"message"`
+}
+
+// NewCmdLint creates a command to check manifests offline
+func NewCmdLint(f cmdutil.Factory, out io.Writer) *cobra.Command {
+	options := &LintOptions{
+		Out: out,
+	}
+	cmd := &cobra.Command{
+		Use:     "lint -f FILENAME",
+		Short:   i18n.T("Check manifests against the rules of the Pi platform"),
+		Long:    lintLong,
+		Example: lintExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	cmdutil.AddFilenameOptionFlags(cmd, &options.FilenameOptions, "to check.")
+	cmdutil.AddTemplateParamFlags(cmd)
+	cmd.Flags().BoolVar(&options.Remote, "remote", options.Remote, "Check that the volumes and secrets used by the pods exist on the server")
+	cmd.Flags().StringVarP(&options.Output, "output", "o", options.Output, "Output format. One of: json")
+	return cmd
+}
+
+// Complete checks the files to lint are given
+func (o *LintOptions) Complete(f cmdutil.Factory, cmd *cobra.Command) error {
+	if cmdutil.IsFilenameSliceEmpty(o.Filenames) {
+		return cmdutil.UsageErrorf(cmd, "Must specify --filename to lint")
+	}
+	if len(o.Output) > 0 && o.Output != "json" {
+		return cmdutil.UsageErrorf(cmd, "Unexpected -o output mode: %v, the only supported mode is json", o.Output)
+	}
+	params, err := cmdutil.GetTemplateParams(cmd)
+	if err != nil {
+		return err
+	}
+	o.Params = params
+	o.f = f
+	return nil
+}
+
+// Run reads the documents of the files, checks them, and prints the problems.
+func (o *LintOptions) Run() error {
+	documents, problems := o.readDocuments()
+
+	platform, err := o.platformSchema(documents)
+	if err != nil {
+		return err
+	}
+	validator, err := o.f.Validator(true)
+	if err != nil {
+		return err
+	}
+	schema := validation.ConjunctiveSchema{validator, platform}
+	for _, document := range documents {
+		platformErrs, errs := validation.PlatformErrors(schema.ValidateBytes(document.Raw))
+		for _, err := range errs {
+			problems = append(problems, document.problem(lintSchemaRule, "", err.Error()))
+		}
+		for _, err := range platformErrs {
+			problems = append(problems, document.problem(err.Rule, err.Field, err.Message))
+		}
+	}
+
+	if o.Output == "json" {
+		data, err := json.MarshalIndent(problems, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(o.Out, string(data))
+	} else {
+		for _, problem := range problems {
+			fmt.Fprintln(o.Out, problem.String())
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d problem(s) found in %d document(s)", len(problems), len(documents))
+	}
+	return nil
+}
+
+// readDocuments reads the documents of the files, the files which can not be read
+// or decoded are reported as problems
+func (o *LintOptions) readDocuments() ([]lintDocument, []lintProblem) {
+	documents := []lintDocument{}
+	problems := []lintProblem{}
+	for _, path := range o.lintFiles(&problems) {
+		var data []byte
+		var err error
+		if path == "-" {
+			data, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			data, err = ioutil.ReadFile(path)
+		}
+		if err == nil && (o.Params != nil || resource.IsTemplate(path)) {
+			data, err = resource.ExpandTemplate(bytes.NewReader(data), o.Params)
+		}
+		if err != nil {
+			problems = append(problems, lintProblem{File: path, Rule: lintSyntaxRule, Message: err.Error()})
+			continue
+		}
+
+		d := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+		for index := 1; ; index++ {
+			ext := runtime.RawExtension{}
+			if err := d.Decode(&ext); err != nil {
+				if err != io.EOF {
+					problems = append(problems, lintProblem{File: path, Document: index, Rule: lintSyntaxRule, Message: err.Error()})
+				}
+				break
+			}
+			ext.Raw = bytes.TrimSpace(ext.Raw)
+			if len(ext.Raw) == 0 || bytes.Equal(ext.Raw, []byte("null")) {
+				index--
+				continue
+			}
+			object := struct {
+				metav1.TypeMeta   `json:",inline"`
+				metav1.ObjectMeta `json:"metadata,omitempty"`
+			}{}
+			document := lintDocument{File: path, Document: index, Raw: ext.Raw}
+			if err := json.Unmarshal(ext.Raw, &object); err != nil {
+				problems = append(problems, document.problem(lintSyntaxRule, "", err.Error()))
+				continue
+			}
+			document.Kind, document.Name = object.Kind, object.Name
+			documents = append(documents, document)
+		}
+	}
+	return documents, problems
+}
+
+// lintFiles returns the files of --filename, the manifests of a directory are
+// the files with a json, yaml or yml extension
+func (o *LintOptions) lintFiles(problems *[]lintProblem) []string {
+	files := []string{}
+	for _, path := range o.Filenames {
+		if path == "-" {
+			files = append(files, path)
+			continue
+		}
+		err := filepath.Walk(path, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if fi.IsDir() {
+				if p != path {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if p == path || sets.NewString(resource.FileExtensions...).Has(filepath.Ext(p)) {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			*problems = append(*problems, lintProblem{File: path, Rule: lintSyntaxRule, Message: err.Error()})
+		}
+	}
+	return files
+}
+
+// platformSchema returns the schema of the platform rules, knowing the volumes and
+// secrets of the documents, and of the server with --remote
+func (o *LintOptions) platformSchema(documents []lintDocument) (validation.PlatformSchema, error) {
+	platform := validation.PlatformSchema{
+		Volumes:  map[string]string{},
+		Secrets:  sets.NewString(),
+		Complete: o.Remote,
+	}
+	for _, document := range documents {
+		switch document.Kind {
+		case resource.VolumeKind:
+			volume := struct {
+				Spec struct {
+					Zone string `json:"zone"`
+				} `json:"spec"`
+			}{}
+			json.Unmarshal(document.Raw, &volume)
+			platform.Volumes[document.Name] = volume.Spec.Zone
+		case "Secret":
+			platform.Secrets.Insert(document.Name)
+		}
+	}
+	if !o.Remote {
+		return platform, nil
+	}
+
+	volumeCli, err := newVolumeClient(o.f, o.Out)
+	if err != nil {
+		return platform, err
+	}
+	volumes, err := volumeCli.list("")
+	if err != nil {
+		return platform, err
+	}
+	for _, volume := range volumes {
+		platform.Volumes[volume.Name] = volume.Zone
+	}
+
+	namespace, _, err := o.f.DefaultNamespace()
+	if err != nil {
+		return platform, err
+	}
+	clientset, err := o.f.ClientSet()
+	if err != nil {
+		return platform, err
+	}
+	secrets, err := clientset.Core().Secrets(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return platform, err
+	}
+	for _, secret := range secrets.Items {
+		platform.Secrets.Insert(secret.Name)
+	}
+	return platform, nil
+}
+
+func (d lintDocument) problem(rule, field, message string) lintProblem {
+	return lintProblem{File: d.File, Document: d.Document, Kind: d.Kind, Name: d.Name, Rule: rule, Field: field, Message: message}
+}
+
+func (p lintProblem) String() string {
+	location := p.File
+	if p.Document > 0 {
+		location = fmt.Sprintf("%s#%d", location, p.Document)
+	}
+	if len(p.Kind) > 0 {
+		location = fmt.Sprintf("%s %s/%s", location, p.Kind, p.Name)
+	}
+	if len(p.Field) > 0 {
+		return fmt.Sprintf("%s: %s: %s (%s)", location, p.Field, p.Message, p.Rule)
+	}
+	return fmt.Sprintf("%s: %s (%s)", location, p.Message, p.Rule)
+}
@@ -17,10 +17,13 @@ limitations under the License.
 package cmd
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"os"
+	"sync"
 	"time"
 
 	restclient "github.com/hyperhq/client-go/rest"
@@ -28,13 +31,16 @@ import (
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
 	"github.com/hyperhq/pi/pkg/pi/util"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+	"github.com/hyperhq/pi/pkg/pi/util/term"
 
 	"github.com/spf13/cobra"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	api "k8s.io/kubernetes/pkg/apis/core"
 	"k8s.io/kubernetes/pkg/apis/core/validation"
+	coreclient "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset/typed/core/internalversion"
 )
 
 var (
@@ -45,6 +51,13 @@ var (
 		# Return snapshot logs for the pods defined by label app=mongo
 		pi logs -lapp=mongo
 
+		# Begin streaming the logs of the mongo container in pod mongo
+		pi logs -f -c mongo mongo
+
+		# Begin streaming the logs of all the pods defined by label app=web, including
+		# the pods started later on, each line prefixed with pod/container
+		pi logs -f -l app=web
+
 		# Return snapshot of previous terminated mongo container logs from pod mongo
 		pi logs -c mongo mongo
 
@@ -55,15 +68,25 @@ var (
 		pi logs --since=1h mongo`))
 
 	selectorTail int64 = 10
+
+	// logPrefixColors are the ANSI colors given in turn to the pod/container
+	// prefix of each stream when following a selector.
+	logPrefixColors = []string{"\x1b[32m", "\x1b[33m", "\x1b[34m", "\x1b[35m", "\x1b[36m", "\x1b[31m"}
 )
 
 const (
 	logsUsageStr = "expected 'logs (POD | TYPE/NAME) [CONTAINER_NAME]'.\nPOD or TYPE/NAME is a required argument for the logs command"
+
+	// selectorPollInterval is how often the selector is evaluated again to
+	// pick up new pods while following logs.
+	selectorPollInterval = 2 * time.Second
 )
 
 type LogsOptions struct {
 	Namespace   string
 	ResourceArg string
+	Selector    string
+	Follow      bool
 	Options     runtime.Object
 
 	Mapper  meta.RESTMapper
@@ -73,6 +96,7 @@ type LogsOptions struct {
 	Object        runtime.Object
 	GetPodTimeout time.Duration
 	LogsForObject func(object, options runtime.Object, timeout time.Duration) (*restclient.Request, error)
+	PodClient     coreclient.PodsGetter
 
 	Out io.Writer
 }
@@ -97,7 +121,7 @@ func NewCmdLogs(f cmdutil.Factory, out io.Writer) *cobra.Command {
 		},
 		Aliases: []string{"log"},
 	}
-	//cmd.Flags().BoolP("follow", "f", false, "Specify if the logs should be streamed.")
+	cmd.Flags().BoolP("follow", "f", false, "Specify if the logs should be streamed. With a selector (-l), stream all the matching pods, including the ones started later.")
 	cmd.Flags().Bool("timestamps", false, "Include timestamps on each line in the log output")
 	cmd.Flags().Int64("limit-bytes", 0, "Maximum bytes of logs to return. Defaults to no limit.")
 	cmd.Flags().BoolP("previous", "p", false, "If true, print the logs for the previous instance of the container in a pod if it exists.")
@@ -141,8 +165,8 @@ func (o *LogsOptions) Complete(f cmdutil.Factory, out io.Writer, cmd *cobra.Comm
 	}
 
 	logOptions := &api.PodLogOptions{
-		Container: containerName,
-		//Follow:     cmdutil.GetFlagBool(cmd, "follow"),
+		Container:  containerName,
+		Follow:     cmdutil.GetFlagBool(cmd, "follow"),
 		Previous:   cmdutil.GetFlagBool(cmd, "previous"),
 		Timestamps: cmdutil.GetFlagBool(cmd, "timestamps"),
 	}
@@ -172,14 +196,21 @@ func (o *LogsOptions) Complete(f cmdutil.Factory, out io.Writer, cmd *cobra.Comm
 	o.Options = logOptions
 	o.LogsForObject = f.LogsForObject
 	o.Out = out
+	o.Selector = selector
+	o.Follow = logOptions.Follow
 
 	if len(selector) != 0 {
-		if logOptions.Follow {
-			return cmdutil.UsageErrorf(cmd, "only one of follow (-f) or selector (-l) is allowed")
-		}
 		if logOptions.TailLines == nil && tail != -1 {
 			logOptions.TailLines = &selectorTail
 		}
+		if o.Follow {
+			clientset, err := f.ClientSet()
+			if err != nil {
+				return err
+			}
+			o.PodClient = clientset.Core()
+			return nil
+		}
 	}
 
 	if o.Object == nil {
@@ -220,6 +251,10 @@ func (o LogsOptions) Validate() error {
 
 // RunLogs retrieves a pod log
 func (o LogsOptions) RunLogs() error {
+	if o.Follow && len(o.Selector) != 0 {
+		return o.followSelector()
+	}
+
 	switch t := o.Object.(type) {
 	case *api.PodList:
 		for _, p := range t.Items {
@@ -248,3 +283,142 @@ func (o LogsOptions) getLogs(obj runtime.Object) error {
 	_, err = io.Copy(o.Out, readCloser)
 	return err
 }
+
+// followSelector streams the logs of every container of the pods matching the
+// selector at the same time, prefixing each line with pod/container. The
+// selector is evaluated again every selectorPollInterval, so pods started
+// later are followed as well, and containers whose stream ended are followed
+// again from the end of that stream unless their pod completed. It returns
+// when the pods can not be listed.
+func (o LogsOptions) followSelector() error {
+	logOptions, ok := o.Options.(*api.PodLogOptions)
+	if !ok {
+		return errors.New("unexpected logs options object")
+	}
+	out := &lockedWriter{w: o.Out}
+	colored := term.IsTerminal(o.Out)
+
+	followed := sets.NewString()
+	// the time the stream of a container ended, and the prefix of its lines
+	ended := map[string]metav1.Time{}
+	prefixes := map[string]string{}
+	done := make(chan string)
+	for initial := true; ; initial = false {
+		pods, err := o.PodClient.Pods(o.Namespace).List(metav1.ListOptions{LabelSelector: o.Selector})
+		if err != nil {
+			return err
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			// the containers of a pending pod have no logs yet, it is picked up by a later poll
+			if pod.Status.Phase == api.PodPending {
+				continue
+			}
+			for _, container := range pod.Spec.Containers {
+				if len(logOptions.Container) != 0 && container.Name != logOptions.Container {
+					continue
+				}
+				key := string(pod.UID) + "/" + container.Name
+				if followed.Has(key) {
+					continue
+				}
+				since, restarted := ended[key]
+				if restarted && (pod.Status.Phase == api.PodSucceeded || pod.Status.Phase == api.PodFailed) {
+					continue
+				}
+
+				options := logOptions.DeepCopy()
+				options.Container = container.Name
+				if restarted {
+					options.TailLines = nil
+					options.SinceSeconds = nil
+					options.SinceTime = &since
+				} else if !initial {
+					// show the whole log of the pods started after pi logs
+					options.TailLines = nil
+				}
+				prefix, found := prefixes[key]
+				if !found {
+					prefix = fmt.Sprintf("%s/%s ", pod.Name, container.Name)
+					if colored {
+						prefix = logPrefixColors[len(prefixes)%len(logPrefixColors)] + prefix + "\x1b[0m"
+					}
+					prefixes[key] = prefix
+				}
+				followed.Insert(key)
+				go func(pod *api.Pod, key string) {
+					o.streamLogs(pod, options, &prefixWriter{prefix: prefix, out: out})
+					done <- key
+				}(pod, key)
+			}
+		}
+
+		poll := time.After(selectorPollInterval)
+		for waiting := true; waiting; {
+			select {
+			case key := <-done:
+				followed.Delete(key)
+				ended[key] = metav1.Now()
+			case <-poll:
+				waiting = false
+			}
+		}
+	}
+}
+
+// streamLogs copies the logs of a container to w. Errors are reported in the
+// stream itself, so that one failing pod does not stop the others.
+func (o LogsOptions) streamLogs(pod *api.Pod, options *api.PodLogOptions, w *prefixWriter) {
+	defer w.Flush()
+	req, err := o.LogsForObject(pod, options, o.GetPodTimeout)
+	if err == nil {
+		var readCloser io.ReadCloser
+		if readCloser, err = req.Stream(); err == nil {
+			defer readCloser.Close()
+			_, err = io.Copy(w, readCloser)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(w, "error: %v\n", err)
+	}
+}
+
+// lockedWriter serializes the writes of concurrent log streams.
+type lockedWriter struct {
+	sync.Mutex
+	w io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.Lock()
+	defer l.Unlock()
+	return l.w.Write(p)
+}
+
+// prefixWriter writes whole lines, each starting with prefix, to out.
+type prefixWriter struct {
+	prefix string
+	out    io.Writer
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		if _, err := w.out.Write(append([]byte(w.prefix), w.buf[:i+1]...)); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+}
+
+// Flush writes the last line when it does not end with a newline.
+func (w *prefixWriter) Flush() {
+	if len(w.buf) > 0 {
+		w.Write([]byte("\n"))
+	}
+}
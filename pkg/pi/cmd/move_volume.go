@@ -0,0 +1,90 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdMoveVolume is a command to move a volume to another zone
+func NewCmdMoveVolume(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "volume NAME --zone=string [--from-zone=string]",
+		Short:   i18n.T("Move a volume to another zone"),
+		Long:    moveVolumeLong,
+		Aliases: []string{"volumes"},
+		Example: moveVolumeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := MoveVolumeGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().String("zone", "", i18n.T("The zone to move the volume to"))
+	cmd.Flags().String("from-zone", "", i18n.T("The zone of the volume, if volumes of that name exist in several zones"))
+	cmd.MarkFlagRequired("zone")
+	return cmd
+}
+
+var (
+	moveVolumeLong = templates.LongDesc(i18n.T(`
+		Move a volume to another zone, e.g. the zone of the pods using it.
+
+		The volume is copied to the zone through a snapshot, then deleted from its zone. The
+		volume can not be used by a pod meanwhile. If the volume can not be deleted, the copy is
+		deleted.`))
+
+	moveVolumeExample = templates.Examples(i18n.T(`
+	  # Move the volume mysql-data to the zone gcp-us-central1-a
+	  pi move volume mysql-data --zone=gcp-us-central1-a`))
+)
+
+// MoveVolumeGeneric is the implementation of the move volume command
+func MoveVolumeGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	name, err := NameFromCommandArgs(cmd, args)
+	if err != nil {
+		return err
+	}
+	zone := cmdutil.GetFlagString(cmd, "zone")
+	if zone == "" {
+		return cmdutil.UsageErrorf(cmd, "--zone is required")
+	}
+
+	volCli, err := newVolumeClient(f, cmdOut)
+	if err != nil {
+		return err
+	}
+	volume, err := volCli.get(name, cmdutil.GetFlagString(cmd, "from-zone"))
+	if err != nil {
+		return err
+	}
+	if volume.Zone == zone {
+		fmt.Fprintf(cmdOut, "volume \"%s\" is already in zone %s\n", name, zone)
+		return nil
+	}
+	if err := volCli.replaceVolume(volume, volume.Name, zone, volume.Size); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmdOut, "volume \"%s\" moved to zone %s\n", name, zone)
+	return nil
+}
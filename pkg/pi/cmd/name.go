@@ -34,11 +34,14 @@ type NameOptions struct {
 
 var (
 	nameLong = templates.LongDesc(i18n.T(`
-		Name a resource(support fip only).`))
+		Name a resource(support fip and volume only).`))
 
 	nameExample = templates.Examples(i18n.T(`
 		# Name a fip.
-		pi name fip x.x.x.x --name=test`))
+		pi name fip x.x.x.x --name=test
+
+		# Rename a volume.
+		pi name volume vol1 --name=vol2`))
 )
 
 func NewCmdName(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
@@ -59,6 +62,7 @@ func NewCmdName(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
 
 	// name fip
 	cmd.AddCommand(NewCmdNameFip(f, out, errOut))
+	cmd.AddCommand(NewCmdNameVolume(f, out, errOut))
 	return cmd
 }
 
@@ -19,9 +19,8 @@ package cmd
 import (
 	"fmt"
 	"io"
-	"log"
 
-	"github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/pi/pkg/hyper"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
@@ -70,10 +69,12 @@ func NameFipGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, arg
 	if cfg, err := f.ClientConfig(); err != nil {
 		return err
 	} else {
-		hyperConn := hyper.NewHyperConn(cfg)
-		fipCli := hyper.NewFipCli(hyperConn)
-		if _, result, err := fipCli.NameFip(ip, name); err != nil {
-			log.Fatal(result)
+		conn, err := hyper.NewConn(cfg)
+		if err != nil {
+			return err
+		}
+		if err := conn.NameFip(ip, name); err != nil {
+			return err
 		} else {
 			fmt.Printf("fip \"%v\" named to \"%v\"\n", ip, name)
 		}
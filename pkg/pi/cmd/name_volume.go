@@ -0,0 +1,88 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdNameVolume is a command to rename a volume
+func NewCmdNameVolume(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "volume NAME --name=string [--zone=string]",
+		Short:   i18n.T("Rename a volume"),
+		Long:    nameVolumeLong,
+		Aliases: []string{"volumes"},
+		Example: nameVolumeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := NameVolumeGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().String("name", "", "The new name of the volume")
+	cmd.Flags().String("zone", "", i18n.T("The zone of the volume"))
+	return cmd
+}
+
+var (
+	nameVolumeLong = templates.LongDesc(i18n.T(`
+		Rename a volume.
+
+		The volume is copied to a volume of the new name through a snapshot, then deleted. The
+		volume can not be used by a pod meanwhile. If the volume can not be deleted, the copy is
+		deleted.`))
+
+	nameVolumeExample = templates.Examples(i18n.T(`
+	  # Rename the volume mysql-data to mysql-prod
+	  pi name volume mysql-data --name=mysql-prod`))
+)
+
+// NameVolumeGeneric is the implementation of the name volume command
+func NameVolumeGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	name, err := NameFromCommandArgs(cmd, args)
+	if err != nil {
+		return err
+	}
+	newName := cmdutil.GetFlagString(cmd, "name")
+	if newName == "" {
+		return fmt.Errorf("--name can not be empty")
+	}
+	if newName == name {
+		return fmt.Errorf("volume %q is already named %q", name, newName)
+	}
+
+	volCli, err := newVolumeClient(f, cmdOut)
+	if err != nil {
+		return err
+	}
+	volume, err := volCli.get(name, cmdutil.GetFlagString(cmd, "zone"))
+	if err != nil {
+		return err
+	}
+	if err := volCli.replaceVolume(volume, newName, volume.Zone, volume.Size); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmdOut, "volume \"%v\" named to \"%v\"\n", name, newName)
+	return nil
+}
@@ -0,0 +1,275 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	restclient "github.com/hyperhq/client-go/rest"
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/golang/glog"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/kubernetes/pkg/apis/core"
+	coreclient "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset/typed/core/internalversion"
+)
+
+var (
+	portForwardLong = templates.LongDesc(i18n.T(`
+		Forward one or more local ports to a pod.
+
+		Every connection to a local port starts a relay process in the container, which connects
+		to the port on the loopback interface of the pod. The container needs socat, nc or bash
+		to run the relay.
+
+		The ports are forwarded until the command is interrupted.`))
+
+	portForwardExample = templates.Examples(i18n.T(`
+		# Listen on port 3306 locally, forwarding to port 3306 in the pod mysql
+		pi port-forward pod/mysql 3306
+
+		# Listen on ports 8080 and 8443 locally, forwarding to ports 80 and 443 in the pod web
+		pi port-forward web 8080:80 8443:443
+
+		# Listen on a random local port, forwarding to port 5432 in the pod postgres
+		pi port-forward pod/postgres :5432
+
+		# Listen on port 3306 on all addresses, forwarding to port 3306 in the pod mysql
+		pi port-forward --address 0.0.0.0 pod/mysql 3306`))
+
+	portForwardUsageStr = "expected 'port-forward POD [LOCAL_PORT:]REMOTE_PORT...'.\n" +
+		"POD is NAME or pod/NAME, a port is REMOTE_PORT, LOCAL_PORT:REMOTE_PORT or :REMOTE_PORT for a random local port"
+)
+
+// relayCommand is run in the container for every forwarded connection, it relays
+// its stdin and stdout to the port on the loopback interface of the pod
+const relayCommand = `if command -v socat >/dev/null 2>&1; then exec socat - TCP:127.0.0.1:%[1]d
+elif command -v nc >/dev/null 2>&1; then exec nc 127.0.0.1 %[1]d
+elif command -v bash >/dev/null 2>&1; then exec bash -c 'exec 3<>/dev/tcp/127.0.0.1/%[1]d && { cat <&3 & cat >&3; wait $!; }'
+else echo "port-forward requires socat, nc or bash in the container" >&2; exit 1; fi`
+
+// PortForwardOptions declare the arguments accepted by the PortForward command
+type PortForwardOptions struct {
+	Namespace string
+	PodName   string
+	Container string
+	Addresses []string
+	Ports     []forwardedPort
+
+	PodClient coreclient.PodsGetter
+	Config    *restclient.Config
+
+	Out io.Writer
+	Err io.Writer
+}
+
+// forwardedPort is a local port forwarded to a port of the pod, a local port 0 is
+// picked by the system
+type forwardedPort struct {
+	Local  uint16
+	Remote uint16
+}
+
+// NewCmdPortForward creates a command to forward local ports to a pod
+func NewCmdPortForward(f cmdutil.Factory, cmdOut, cmdErr io.Writer) *cobra.Command {
+	options := &PortForwardOptions{
+		Out: cmdOut,
+		Err: cmdErr,
+	}
+	cmd := &cobra.Command{
+		Use:     "port-forward POD [LOCAL_PORT:]REMOTE_PORT [...[LOCAL_PORT_N:]REMOTE_PORT_N]",
+		Short:   i18n.T("Forward one or more local ports to a pod"),
+		Long:    portForwardLong,
+		Example: portForwardExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	cmd.Flags().StringVarP(&options.Container, "container", "c", "", "Container name. If omitted, the first container in the pod will be chosen")
+	cmd.Flags().StringSliceVar(&options.Addresses, "address", []string{"localhost"}, "Addresses to listen on (comma separated), localhost and IP addresses only")
+	return cmd
+}
+
+// Complete completes all the required options for port-forward.
+func (o *PortForwardOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return cmdutil.UsageErrorf(cmd, portForwardUsageStr)
+	}
+	o.PodName = args[0]
+	if i := strings.Index(o.PodName, "/"); i >= 0 {
+		if resourceType := o.PodName[:i]; resourceType != "pod" && resourceType != "pods" && resourceType != "po" {
+			return cmdutil.UsageErrorf(cmd, "only pods can be port forwarded, not %s", resourceType)
+		}
+		o.PodName = o.PodName[i+1:]
+	}
+	if len(o.PodName) == 0 {
+		return cmdutil.UsageErrorf(cmd, portForwardUsageStr)
+	}
+	ports, err := parseForwardedPorts(args[1:])
+	if err != nil {
+		return cmdutil.UsageErrorf(cmd, err.Error())
+	}
+	o.Ports = ports
+
+	namespace, _, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	config, err := f.ClientConfig()
+	if err != nil {
+		return err
+	}
+	o.Config = config
+
+	clientset, err := f.ClientSet()
+	if err != nil {
+		return err
+	}
+	o.PodClient = clientset.Core()
+	return nil
+}
+
+// parseForwardedPorts parses the REMOTE_PORT, LOCAL_PORT:REMOTE_PORT and :REMOTE_PORT args
+func parseForwardedPorts(args []string) ([]forwardedPort, error) {
+	ports := []forwardedPort{}
+	for _, arg := range args {
+		local, remote := arg, arg
+		if i := strings.Index(arg, ":"); i >= 0 {
+			local, remote = arg[:i], arg[i+1:]
+			if len(local) == 0 {
+				local = "0"
+			}
+		}
+		localPort, err := strconv.ParseUint(local, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid local port %q in %q", local, arg)
+		}
+		remotePort, err := strconv.ParseUint(remote, 10, 16)
+		if err != nil || remotePort == 0 {
+			return nil, fmt.Errorf("invalid remote port %q in %q", remote, arg)
+		}
+		ports = append(ports, forwardedPort{Local: uint16(localPort), Remote: uint16(remotePort)})
+	}
+	return ports, nil
+}
+
+// Run listens on the local ports and forwards their connections until interrupted.
+func (o *PortForwardOptions) Run() error {
+	pod, err := o.PodClient.Pods(o.Namespace).Get(o.PodName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if pod.Status.Phase != api.PodRunning {
+		return fmt.Errorf("unable to forward port because pod is not running. Current status=%v", pod.Status.Phase)
+	}
+	containerName := o.Container
+	if len(containerName) == 0 {
+		if len(pod.Spec.Containers) > 1 {
+			fmt.Fprintf(o.Err, "Defaulting container name to %s.\n", pod.Spec.Containers[0].Name)
+		}
+		containerName = pod.Spec.Containers[0].Name
+	}
+
+	cli, err := hyper.NewHyperCli(o.Config.Host, o.Config, nil, o.Out, o.Err)
+	if err != nil {
+		return err
+	}
+
+	listeners := []net.Listener{}
+	defer func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}()
+	for _, port := range o.Ports {
+		for _, address := range o.Addresses {
+			listener, err := net.Listen("tcp", net.JoinHostPort(address, strconv.Itoa(int(port.Local))))
+			if err != nil {
+				return fmt.Errorf("unable to listen on port %d: %v", port.Local, err)
+			}
+			listeners = append(listeners, listener)
+			fmt.Fprintf(o.Out, "Forwarding from %s -> %d\n", listener.Addr(), port.Remote)
+			go o.accept(cli, pod.Name, containerName, listener, port.Remote)
+		}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	<-signals
+	return nil
+}
+
+// accept forwards the connections of listener to the remote port, until the
+// listener is closed
+func (o *PortForwardOptions) accept(cli *hyper.HyperCli, podName, containerName string, listener net.Listener, remotePort uint16) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			glog.V(4).Infof("stop accepting on %s: %v", listener.Addr(), err)
+			return
+		}
+		go func() {
+			defer conn.Close()
+			fmt.Fprintf(o.Out, "Handling connection for %d\n", remotePort)
+			if err := forwardConnection(cli, podName, containerName, conn, remotePort, o.Err); err != nil {
+				fmt.Fprintf(o.Err, "error forwarding port %d to pod %s: %v\n", remotePort, podName, err)
+			}
+		}()
+	}
+}
+
+// forwardConnection relays conn to the remote port through an exec of the relay
+// command in the container
+func forwardConnection(cli *hyper.HyperCli, podName, containerName string, conn net.Conn, remotePort uint16, errOut io.Writer) error {
+	execConfig := types.ExecConfig{
+		Cmd:          []string{"sh", "-c", fmt.Sprintf(relayCommand, remotePort)},
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	ctx := context.Background()
+	response, err := cli.Client.PodExecCreate(ctx, podName, containerName, execConfig)
+	if err != nil {
+		return err
+	}
+	if response.ID == "" {
+		return fmt.Errorf("exec ID empty")
+	}
+	resp, err := cli.Client.PodExecAttach(ctx, response.ID, execConfig)
+	if err != nil {
+		return err
+	}
+	defer resp.Close()
+	return cli.HoldHijackedConnection(false, conn, conn, errOut, resp)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+// NewCmdResizeVolume is a command to resize a volume
+func NewCmdResizeVolume(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "volume NAME --size=int [--zone=string]",
+		Short:   i18n.T("Resize a volume"),
+		Long:    resizeVolumeLong,
+		Aliases: []string{"volumes"},
+		Example: resizeVolumeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			err := ResizeVolumeGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().Int("size", 0, i18n.T("The new size of the volume in GB, it can not be smaller than the current size"))
+	cmd.Flags().String("zone", "", i18n.T("The zone of the volume"))
+	cmd.MarkFlagRequired("size")
+	return cmd
+}
+
+var (
+	resizeVolumeLong = templates.LongDesc(i18n.T(`
+		Resize a volume.
+
+		The volume is copied to a volume of the new size through a snapshot, and replaced by the
+		copy. The volume can not be used by a pod meanwhile. If the copy fails, the volume is
+		restored from the snapshot.`))
+
+	resizeVolumeExample = templates.Examples(i18n.T(`
+	  # Resize the volume mysql-data to 50GB
+	  pi resize volume mysql-data --size=50`))
+)
+
+// ResizeVolumeGeneric is the implementation of the resize volume command
+func ResizeVolumeGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	name, err := NameFromCommandArgs(cmd, args)
+	if err != nil {
+		return err
+	}
+	size := cmdutil.GetFlagInt(cmd, "size")
+	if size <= 0 {
+		return cmdutil.UsageErrorf(cmd, "--size must be a positive number of GB")
+	}
+
+	volCli, err := newVolumeClient(f, cmdOut)
+	if err != nil {
+		return err
+	}
+	volume, err := volCli.get(name, cmdutil.GetFlagString(cmd, "zone"))
+	if err != nil {
+		return err
+	}
+	if size < volume.Size {
+		return fmt.Errorf("volume %q can not be shrunk from %dGB to %dGB", name, volume.Size, size)
+	}
+	if size == volume.Size {
+		fmt.Fprintf(cmdOut, "volume \"%s\" is already %dGB\n", name, size)
+		return nil
+	}
+	if err := volCli.replaceVolume(volume, volume.Name, volume.Zone, size); err != nil {
+		return err
+	}
+	fmt.Fprintf(cmdOut, "volume \"%s\" resized to %dGB\n", name, size)
+	return nil
+}
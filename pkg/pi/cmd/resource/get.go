@@ -88,7 +88,13 @@ var (
 		pi get pods,services,secret
 
 		# List one or more resources by their type and names.
-		pi get services/nginx pods/nginx`))
+		pi get services/nginx pods/nginx
+
+		# List all pods, then print a row every time a pod changes.
+		pi get pods -w
+
+		# Print a row every time a pod labelled app=nginx changes, without listing them first.
+		pi get pods -l app=nginx --watch-only`))
 )
 
 const (
@@ -132,8 +138,8 @@ func NewCmdGet(f cmdutil.Factory, out io.Writer, errOut io.Writer) *cobra.Comman
 	}
 
 	//cmd.Flags().StringVar(&options.Raw, "raw", options.Raw, "Raw URI to request from the server.  Uses the transport specified by the config file.")
-	//cmd.Flags().BoolVarP(&options.Watch, "watch", "w", options.Watch, "After listing/getting the requested object, watch for changes. Uninitialized objects are excluded if no object name is provided.")
-	//cmd.Flags().BoolVar(&options.WatchOnly, "watch-only", options.WatchOnly, "Watch for changes to the requested object(s), without listing/getting first.")
+	cmd.Flags().BoolVarP(&options.Watch, "watch", "w", options.Watch, "After listing/getting the requested object, watch for changes. Uninitialized objects are excluded if no object name is provided.")
+	cmd.Flags().BoolVar(&options.WatchOnly, "watch-only", options.WatchOnly, "Watch for changes to the requested object(s), without listing/getting first.")
 	//cmd.Flags().Int64Var(&options.ChunkSize, "chunk-size", 500, "Return large lists in chunks rather than all at once. Pass 0 to disable. This flag is beta and may change in the future.")
 	//cmd.Flags().BoolVar(&options.IgnoreNotFound, "ignore-not-found", options.IgnoreNotFound, "If the requested object does not exist the command will return exit code 0.")
 	cmd.Flags().StringVarP(&options.LabelSelector, "selector", "l", options.LabelSelector, "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
@@ -150,6 +156,9 @@ func NewCmdGet(f cmdutil.Factory, out io.Writer, errOut io.Writer) *cobra.Comman
 	// get volume, fip
 	cmd.AddCommand(NewCmdGetVolume(f, out, errOut))
 	cmd.AddCommand(NewCmdGetFip(f, out, errOut))
+	cmd.AddCommand(NewCmdGetSnapshot(f, out, errOut))
+	cmd.AddCommand(NewCmdGetSecurityGroup(f, out, errOut))
+	cmd.AddCommand(NewCmdGetCronJob(f, out, errOut))
 	return cmd
 }
 
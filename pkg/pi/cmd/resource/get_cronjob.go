@@ -0,0 +1,218 @@
+package resource
+
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdGetCronJob is a command to list cronjobs, get a cronjob or its history
+func NewCmdGetCronJob(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "cronjob NAME [--history]",
+		Short:   i18n.T("list cronjobs, get a cronjob or the runs of a cronjob"),
+		Long:    cronJobLong,
+		Example: cronJobExample,
+		Aliases: []string{"cronjobs", "cj"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := GetCronJobGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|name")
+	cmd.Flags().Bool("history", false, i18n.T("List the runs of the cronjob and their exit status"))
+	cmd.Flags().String("since", "", i18n.T("Only list the runs since a timestamp (e.g. 2013-01-02T13:23:37) or relative (e.g. 42m for 42 minutes), with --history"))
+	cmd.Flags().String("tail", "", i18n.T("Number of runs to list from the end of the history, with --history"))
+	return cmd
+}
+
+var (
+	cronJobLong = templates.LongDesc(i18n.T(`List cronjobs, get a cronjob or the runs of a cronjob.`))
+
+	cronJobExample = templates.Examples(i18n.T(`
+	  # List cronjobs
+	  pi get cronjobs
+
+	  # Get a cronjob named report
+	  pi get cronjob report -o json
+
+	  # List the last 10 runs of the cronjob report
+	  pi get cronjob report --history --tail=10`))
+)
+
+// GetCronJobGeneric is the implementation of the get cronjob command
+func GetCronJobGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	name := VolNameFromCommandArgs(cmd, args)
+	output := cmdutil.GetFlagString(cmd, "output")
+	history := cmdutil.GetFlagBool(cmd, "history")
+	if history && name == "" {
+		return cmdutil.UsageErrorf(cmd, "NAME is required for --history")
+	}
+
+	if cfg, err := f.ClientConfig(); err != nil {
+		return err
+	} else {
+		cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		if history {
+			events, err := cli.Client.CronHistory(ctx, name, cmdutil.GetFlagString(cmd, "since"), cmdutil.GetFlagString(cmd, "tail"))
+			if err != nil {
+				return err
+			}
+			if len(events) == 0 {
+				fmt.Println("No resources found.")
+				return nil
+			}
+			return PrintCronJobHistory(output, events)
+		}
+		if name == "" {
+			cronList, err := cli.Client.CronList(ctx, types.CronListOptions{})
+			if err != nil {
+				return err
+			}
+			if len(cronList) == 0 {
+				fmt.Println("No resources found.")
+			} else {
+				return PrintCronJobResult(output, true, cronList)
+			}
+		} else {
+			cron, err := cli.Client.CronInspect(ctx, name)
+			if err != nil {
+				return err
+			}
+			return PrintCronJobResult(output, false, []types.Cron{cron})
+		}
+	}
+	return nil
+}
+
+func PrintCronJobResult(output string, isList bool, result []types.Cron) error {
+	// the credentials the cron runs with are never printed
+	for i := range result {
+		result[i].AccessKey = ""
+		result[i].SecretKey = ""
+	}
+
+	if output == "" {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Name", "Schedule", "Image", "LastRun", "Succeeded", "Failed"})
+
+		//set table style
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetRowLine(false)
+		table.SetColumnSeparator("")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT})
+
+		for _, cron := range result {
+			image, lastRun := "", "<none>"
+			if cron.Config != nil {
+				image = cron.Config.Image
+			}
+			if !cron.LastRun.IsZero() {
+				lastRun = cron.LastRun.Format("2006-01-02T15:04:05-07:00")
+			}
+			table.Append([]string{cron.Name, cron.Schedule, image, lastRun, fmt.Sprint(cron.SuccessCount), fmt.Sprint(cron.ErrorCount)})
+		}
+		table.Render()
+	} else if output == "json" {
+		var (
+			buf []byte
+			err error
+		)
+		if !isList {
+			buf, err = json.MarshalIndent(result[0], "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			buf, err = json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		fmt.Print(string(buf))
+	} else if output == "name" {
+		for _, cron := range result {
+			fmt.Printf("cronjobs/%v\n", cron.Name)
+		}
+	} else {
+		err := fmt.Errorf("error: output format \"%v\" not recognized", output)
+		return err
+	}
+	return nil
+}
+
+// PrintCronJobHistory prints the runs of a cronjob, one per line
+func PrintCronJobHistory(output string, events []types.Event) error {
+	if output == "" {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Job", "StartedAt", "FinishedAt", "Status", "Message"})
+
+		//set table style
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetRowLine(false)
+		table.SetColumnSeparator("")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT})
+
+		for _, event := range events {
+			table.Append([]string{event.Job, cronEventTime(event.StartedAt), cronEventTime(event.FinishedAt), event.Status, event.Message})
+		}
+		table.Render()
+	} else if output == "json" {
+		buf, err := json.MarshalIndent(events, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print(string(buf))
+	} else {
+		err := fmt.Errorf("error: output format \"%v\" not recognized", output)
+		return err
+	}
+	return nil
+}
+
+// cronEventTime formats the unix timestamp of a cron run
+func cronEventTime(t int64) string {
+	if t == 0 {
+		return "<none>"
+	}
+	return time.Unix(t, 0).Format("2006-01-02T15:04:05-07:00")
+}
@@ -21,11 +21,11 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 	"strings"
 
-	"github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	hyperclient "github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/pi/pkg/hyper"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
@@ -48,6 +48,7 @@ func NewCmdGetFip(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
 		},
 	}
 	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|ip")
+	addPollWatchFlags(cmd)
 	return cmd
 }
 
@@ -62,21 +63,38 @@ var (
 	  pi get fip x.x.x.x
 
 	  # Show ip only
-	  pi get fip -o ip`))
+	  pi get fip -o ip
+
+	  # List fips, then print the fips allocated, named, attached or released
+	  pi get fips -w`))
+
+	fipHeader = []string{"Fip", "Name", "CreatedAt", "Services"}
 )
 
 // GetFipGeneric is the implementation of the get fip generic command
 func GetFipGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
 	ip := IPFromCommandArgs(cmd, args)
 	output := cmdutil.GetFlagString(cmd, "output")
+	watch := cmdutil.GetFlagBool(cmd, "watch")
+	watchOnly := cmdutil.GetFlagBool(cmd, "watch-only")
 
 	if cfg, err := f.ClientConfig(); err != nil {
 		return err
 	} else {
-		hyperConn := hyper.NewHyperConn(cfg)
-		fipCli := hyper.NewFipCli(hyperConn)
+		conn, err := hyper.NewConn(cfg)
+		if err != nil {
+			return err
+		}
+		if watch || watchOnly {
+			if output != "" {
+				return fmt.Errorf("--watch is only supported with the default output format")
+			}
+			return watchByPolling(cmdOut, fipHeader, watchOnly, func() (map[string][]string, error) {
+				return listFipRows(conn, ip)
+			})
+		}
 		if ip == "" {
-			if _, fipList, err := fipCli.ListFips(); err != nil {
+			if fipList, err := conn.ListFips(); err != nil {
 				return err
 			} else {
 				if len(fipList) == 0 {
@@ -86,29 +104,26 @@ func GetFipGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args
 				}
 			}
 		} else {
-			if httpStatus, fip, err := fipCli.GetFip(ip); err != nil {
+			if fip, err := conn.GetFip(ip); hyper.IsNotFound(err) {
+				fmt.Println("No resources found.")
+			} else if err != nil {
 				return err
 			} else {
-				if httpStatus == http.StatusNotFound {
-					fmt.Println("No resources found.")
-				} else {
-					return PrintFipResult(output, false, []hyper.FipResponse{*fip})
-				}
+				return PrintFipResult(output, false, []hyperclient.FipResponse{*fip})
 			}
 		}
 	}
 	return nil
 }
 
-func PrintFipResult(output string, isList bool, result []hyper.FipResponse) error {
+func PrintFipResult(output string, isList bool, result []hyperclient.FipResponse) error {
 	if output == "" {
 		data := [][]string{}
 		for _, fip := range result {
-			item := []string{fip.Fip, fip.Name, fip.CreatedAt.Format("2006-01-02T15:04:05-07:00"), strings.Join(fip.Services, ",")}
-			data = append(data, item)
+			data = append(data, fipRow(fip))
 		}
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Fip", "Name", "CreatedAt", "Services"})
+		table.SetHeader(fipHeader)
 
 		//set table style
 		table.SetBorder(false)
@@ -150,6 +165,27 @@ func PrintFipResult(output string, isList bool, result []hyper.FipResponse) erro
 	return nil
 }
 
+func fipRow(fip hyperclient.FipResponse) []string {
+	return []string{fip.Fip, fip.Name, fip.CreatedAt.Format("2006-01-02T15:04:05-07:00"), strings.Join(fip.Services, ",")}
+}
+
+// listFipRows returns the rows of all the fips, or of the fip ip only when it
+// is set, keyed by ip. The fip is looked up in the listing, GetFip fails when
+// the fip is not found, e.g. once released.
+func listFipRows(conn *hyper.Conn, ip string) (map[string][]string, error) {
+	rows := map[string][]string{}
+	fipList, err := conn.ListFips()
+	if err != nil {
+		return nil, err
+	}
+	for _, fip := range fipList {
+		if ip == "" || fip.Fip == ip {
+			rows[fip.Fip] = fipRow(fip)
+		}
+	}
+	return rows, nil
+}
+
 func IPFromCommandArgs(cmd *cobra.Command, args []string) string {
 	if len(args) == 0 {
 		return ""
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyperhq/pi/pkg/printers"
+
+	"github.com/spf13/cobra"
+)
+
+// pollInterval is how often volumes and fips are listed again by --watch.
+// The Hyper api can not watch them, so changes are found by diffing listings.
+var pollInterval = 2 * time.Second
+
+// addPollWatchFlags adds the --watch and --watch-only flags to the get
+// subcommands of resources that are watched by polling.
+func addPollWatchFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolP("watch", "w", false, "After listing/getting the requested object, watch for changes.")
+	cmd.Flags().Bool("watch-only", false, "Watch for changes to the requested object(s), without listing/getting first.")
+}
+
+// rowLister lists the rows of the resources to watch, keyed by resource name.
+type rowLister func() (map[string][]string, error)
+
+// watchByPolling prints the rows returned by list, then lists again every
+// pollInterval and prints the rows that were added, changed or removed since
+// the previous listing, the removed rows ending with DELETED. With watchOnly,
+// the first listing is not printed.
+func watchByPolling(out io.Writer, header []string, watchOnly bool, list rowLister) error {
+	w := printers.GetNewTabWriter(out)
+	printedHeader := false
+	printRow := func(row []string) {
+		if !printedHeader {
+			fmt.Fprintln(w, strings.ToUpper(strings.Join(header, "\t")))
+			printedHeader = true
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+
+	var previous map[string][]string
+	for {
+		current, err := list()
+		if err != nil {
+			return err
+		}
+
+		if previous != nil || !watchOnly {
+			for _, key := range sortedKeys(current) {
+				if old, found := previous[key]; !found || !reflect.DeepEqual(old, current[key]) {
+					printRow(current[key])
+				}
+			}
+			for _, key := range sortedKeys(previous) {
+				if _, found := current[key]; !found {
+					printRow(append(previous[key], "DELETED"))
+				}
+			}
+			w.Flush()
+		}
+
+		previous = current
+		time.Sleep(pollInterval)
+	}
+}
+
+func sortedKeys(rows map[string][]string) []string {
+	keys := make([]string, 0, len(rows))
+	for key := range rows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,167 @@
+package resource
+
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// NewCmdGetSecurityGroup is a command to list security groups or get a security group
+func NewCmdGetSecurityGroup(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "securitygroup NAME",
+		Short:   i18n.T("list security groups or get a security group"),
+		Long:    securityGroupLong,
+		Example: securityGroupExample,
+		Aliases: []string{"securitygroups", "sg"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := GetSecurityGroupGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|yaml|name")
+	return cmd
+}
+
+var (
+	securityGroupLong = templates.LongDesc(i18n.T(`List security groups or get a security group.`))
+
+	securityGroupExample = templates.Examples(i18n.T(`
+	  # List security groups
+	  pi get securitygroups
+
+	  # Get the rules of the security group web, in the format of 'pi create securitygroup -f'
+	  pi get securitygroup web -o yaml
+
+	  # Show security group name only
+	  pi get securitygroups -o name`))
+)
+
+// GetSecurityGroupGeneric is the implementation of the get securitygroup command
+func GetSecurityGroupGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	name := VolNameFromCommandArgs(cmd, args)
+	output := cmdutil.GetFlagString(cmd, "output")
+
+	if cfg, err := f.ClientConfig(); err != nil {
+		return err
+	} else {
+		cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		if name == "" {
+			sgList, err := cli.Client.SgLs(ctx)
+			if err != nil {
+				return err
+			}
+			if len(sgList) == 0 {
+				fmt.Println("No resources found.")
+			} else {
+				return PrintSecurityGroupResult(output, true, sgList)
+			}
+		} else {
+			sg, err := cli.Client.SgInspect(ctx, name)
+			if err != nil {
+				return err
+			}
+			return PrintSecurityGroupResult(output, false, []types.SecurityGroup{*sg})
+		}
+	}
+	return nil
+}
+
+func PrintSecurityGroupResult(output string, isList bool, result []types.SecurityGroup) error {
+	if output == "" {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Name", "Description", "Rules"})
+
+		//set table style
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetRowLine(false)
+		table.SetColumnSeparator("")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT})
+
+		for _, sg := range result {
+			rules := []string{}
+			for _, rule := range sg.Rules {
+				rules = append(rules, securityGroupRuleString(rule))
+			}
+			table.Append([]string{sg.GroupName, sg.Description, strings.Join(rules, ",")})
+		}
+		table.Render()
+	} else if output == "json" || output == "yaml" {
+		var (
+			buf []byte
+			err error
+		)
+		var obj interface{} = result
+		if !isList {
+			obj = result[0]
+		}
+		if output == "json" {
+			buf, err = json.MarshalIndent(obj, "", "  ")
+		} else {
+			buf, err = yaml.Marshal(obj)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Print(string(buf))
+	} else if output == "name" {
+		for _, sg := range result {
+			fmt.Printf("securitygroups/%v\n", sg.GroupName)
+		}
+	} else {
+		err := fmt.Errorf("error: output format \"%v\" not recognized", output)
+		return err
+	}
+	return nil
+}
+
+// securityGroupRuleString returns a short form of rule, e.g.
+// ingress:tcp:80-80:0.0.0.0/0
+func securityGroupRuleString(rule types.Rule) string {
+	protocol := rule.Protocol
+	if protocol == "" {
+		protocol = "any"
+	}
+	remote := rule.RemoteIPPrefix
+	if rule.RemoteGroupName != "" {
+		remote = rule.RemoteGroupName
+	}
+	return fmt.Sprintf("%v:%v:%v-%v:%v", rule.Direction, protocol, rule.PortRangeMin, rule.PortRangeMax, remote)
+}
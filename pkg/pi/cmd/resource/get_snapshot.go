@@ -0,0 +1,163 @@
+package resource
+
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/hyper-api/types/filters"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// NewCmdGetSnapshot is a command to list snapshots or get a snapshot
+func NewCmdGetSnapshot(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "snapshot NAME [--volume=string]",
+		Short:   i18n.T("list snapshots or get a snapshot"),
+		Long:    snapshotLong,
+		Example: snapshotExample,
+		Aliases: []string{"snapshots"},
+		Run: func(cmd *cobra.Command, args []string) {
+			err := GetSnapshotGeneric(f, cmdOut, cmd, args)
+			cmdutil.CheckErr(err)
+		},
+	}
+	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|name")
+	cmd.Flags().String("volume", "", i18n.T("Only list the snapshots of this volume"))
+	return cmd
+}
+
+var (
+	snapshotLong = templates.LongDesc(i18n.T(`List snapshots or get a snapshot.`))
+
+	snapshotExample = templates.Examples(i18n.T(`
+	  # List snapshots
+	  pi get snapshots
+
+	  # Get a snapshot named snap1
+	  pi get snapshot snap1
+
+	  # List the snapshots of the volume mysql-data
+	  pi get snapshots --volume=mysql-data
+
+	  # Show snapshot name only
+	  pi get snapshots -o name`))
+)
+
+// GetSnapshotGeneric is the implementation of the get snapshot command
+func GetSnapshotGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, args []string) error {
+	name := VolNameFromCommandArgs(cmd, args)
+	volume := cmdutil.GetFlagString(cmd, "volume")
+	output := cmdutil.GetFlagString(cmd, "output")
+
+	if cfg, err := f.ClientConfig(); err != nil {
+		return err
+	} else {
+		cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, os.Stdout, os.Stderr)
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		if name == "" {
+			snapFilters := filters.NewArgs()
+			if volume != "" {
+				snapFilters.Add("volume", volume)
+			}
+			snapList, err := cli.Client.SnapshotList(ctx, snapFilters)
+			if err != nil {
+				return err
+			}
+			result := []types.Snapshot{}
+			for _, snap := range snapList.Snapshots {
+				// the filter is also applied here, in case the server ignores it
+				if volume == "" || snap.Volume == volume {
+					result = append(result, *snap)
+				}
+			}
+			if len(result) == 0 {
+				fmt.Println("No resources found.")
+			} else {
+				return PrintSnapshotResult(output, true, result)
+			}
+		} else {
+			snap, err := cli.Client.SnapshotInspect(ctx, name)
+			if err != nil {
+				return err
+			}
+			return PrintSnapshotResult(output, false, []types.Snapshot{snap})
+		}
+	}
+	return nil
+}
+
+func PrintSnapshotResult(output string, isList bool, result []types.Snapshot) error {
+	if output == "" {
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Name", "Volume", "Size(GB)"})
+
+		//set table style
+		table.SetBorder(false)
+		table.SetHeaderLine(false)
+		table.SetRowLine(false)
+		table.SetColumnSeparator("")
+		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+		table.SetAlignment(tablewriter.ALIGN_LEFT)
+		table.SetColumnAlignment([]int{tablewriter.ALIGN_LEFT})
+
+		for _, snap := range result {
+			table.Append([]string{snap.Name, snap.Volume, fmt.Sprint(snap.Size)})
+		}
+		table.Render()
+	} else if output == "json" {
+		var (
+			buf []byte
+			err error
+		)
+		if !isList {
+			buf, err = json.MarshalIndent(result[0], "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			buf, err = json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		fmt.Print(string(buf))
+	} else if output == "name" {
+		for _, snap := range result {
+			fmt.Printf("snapshots/%v\n", snap.Name)
+		}
+	} else {
+		err := fmt.Errorf("error: output format \"%v\" not recognized", output)
+		return err
+	}
+	return nil
+}
@@ -21,10 +21,10 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"net/http"
 	"os"
 
-	"github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	hyperclient "github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/pi/pkg/hyper"
 	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
 	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
 	"github.com/hyperhq/pi/pkg/pi/util/i18n"
@@ -48,6 +48,7 @@ func NewCmdGetVolume(f cmdutil.Factory, cmdOut, errOut io.Writer) *cobra.Command
 	}
 	cmd.Flags().StringP("output", "o", "", "Output format. One of: json|name")
 	cmd.Flags().String("zone", "", i18n.T("The zone of volume to get"))
+	addPollWatchFlags(cmd)
 	return cmd
 }
 
@@ -65,7 +66,12 @@ var (
 	  pi get volumes --zone=gcp-us-central1-b
 
 	  # Show volume name only
-	  pi get volumes -o name`))
+	  pi get volumes -o name
+
+	  # List volumes, then print the volumes created, changed or deleted
+	  pi get volumes -w`))
+
+	volumeHeader = []string{"Name", "Zone", "Size(GB)", "CreatedAt", "Pod", "Job"}
 )
 
 // GetVolumeGeneric is the implementation of the get volume generic command
@@ -73,14 +79,26 @@ func GetVolumeGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, a
 	name := VolNameFromCommandArgs(cmd, args)
 	zone := cmdutil.GetFlagString(cmd, "zone")
 	output := cmdutil.GetFlagString(cmd, "output")
+	watch := cmdutil.GetFlagBool(cmd, "watch")
+	watchOnly := cmdutil.GetFlagBool(cmd, "watch-only")
 
 	if cfg, err := f.ClientConfig(); err != nil {
 		return err
 	} else {
-		hyperConn := hyper.NewHyperConn(cfg)
-		volCli := hyper.NewVolumeCli(hyperConn)
+		conn, err := hyper.NewConn(cfg)
+		if err != nil {
+			return err
+		}
+		if watch || watchOnly {
+			if output != "" {
+				return fmt.Errorf("--watch is only supported with the default output format")
+			}
+			return watchByPolling(cmdOut, volumeHeader, watchOnly, func() (map[string][]string, error) {
+				return listVolumeRows(conn, name, zone)
+			})
+		}
 		if name == "" {
-			if _, volList, err := volCli.ListVolumes(zone); err != nil {
+			if volList, err := conn.ListVolumes(zone); err != nil {
 				return err
 			} else {
 				if len(volList) == 0 {
@@ -90,29 +108,26 @@ func GetVolumeGeneric(f cmdutil.Factory, cmdOut io.Writer, cmd *cobra.Command, a
 				}
 			}
 		} else {
-			if httpStatus, vol, err := volCli.GetVolume(name, zone); err != nil {
+			if vol, err := conn.GetVolume(name, zone); hyper.IsNotFound(err) {
+				fmt.Println("No resources found.")
+			} else if err != nil {
 				return err
 			} else {
-				if httpStatus == http.StatusNotFound {
-					fmt.Println("No resources found.")
-				} else {
-					return PrintVolumeResult(output, false, []hyper.VolumeResponse{*vol})
-				}
+				return PrintVolumeResult(output, false, []hyperclient.VolumeResponse{*vol})
 			}
 		}
 	}
 	return nil
 }
 
-func PrintVolumeResult(output string, isList bool, result []hyper.VolumeResponse) error {
+func PrintVolumeResult(output string, isList bool, result []hyperclient.VolumeResponse) error {
 	if output == "" {
 		data := [][]string{}
 		for _, vol := range result {
-			item := []string{vol.Name, vol.Zone, fmt.Sprint(vol.Size), vol.CreatedAt.Format("2006-01-02T15:04:05-07:00"), vol.Pod, vol.Job}
-			data = append(data, item)
+			data = append(data, volumeRow(vol))
 		}
 		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Name", "Zone", "Size(GB)", "CreatedAt", "Pod", "Job"})
+		table.SetHeader(volumeHeader)
 
 		//set table style
 		table.SetBorder(false)
@@ -155,6 +170,27 @@ func PrintVolumeResult(output string, isList bool, result []hyper.VolumeResponse
 	return nil
 }
 
+func volumeRow(vol hyperclient.VolumeResponse) []string {
+	return []string{vol.Name, vol.Zone, fmt.Sprint(vol.Size), vol.CreatedAt.Format("2006-01-02T15:04:05-07:00"), vol.Pod, vol.Job}
+}
+
+// listVolumeRows returns the rows of the volumes in zone, or of the volume name
+// only when it is set, keyed by volume name. The volume name is looked up in the
+// listing, GetVolume fails when the volume is not found, e.g. once deleted.
+func listVolumeRows(conn *hyper.Conn, name, zone string) (map[string][]string, error) {
+	rows := map[string][]string{}
+	volList, err := conn.ListVolumes(zone)
+	if err != nil {
+		return nil, err
+	}
+	for _, vol := range volList {
+		if name == "" || vol.Name == name {
+			rows[vol.Name] = volumeRow(vol)
+		}
+	}
+	return rows, nil
+}
+
 func VolNameFromCommandArgs(cmd *cobra.Command, args []string) string {
 	if len(args) == 0 {
 		return ""
@@ -0,0 +1,416 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperhq/pi/pkg/pi"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/kubernetes/pkg/api/legacyscheme"
+	api "k8s.io/kubernetes/pkg/apis/core"
+	coreclient "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset/typed/core/internalversion"
+)
+
+const defaultRolloutTimeout = 5 * time.Minute
+
+var (
+	rolloutLong = templates.LongDesc(i18n.T(`
+		Manage the rollout of new pod specs.
+
+		Pods can't be updated in place, a rollout replaces each pod by a new pod, one batch of
+		--max-surge pods at a time, so that a service keeps routing to ready pods. The new pods
+		have the labels of the pods they replace, and record the previous pod template in the
+		annotation sh_hyper_previous_template, for 'pi rollout undo'.
+
+		Valid sub-commands:
+		  * replace
+		  * undo
+		  * history`))
+
+	rolloutExample = templates.Examples(i18n.T(`
+		# Replace the pods labeled app=web with pods running nginx:1.15
+		pi rollout replace -l app=web --image=nginx:1.15
+
+		# Roll the pods labeled app=web back to their previous spec
+		pi rollout undo -l app=web`))
+)
+
+// NewCmdRollout groups the subcommands to roll out pods
+func NewCmdRollout(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rollout SUBCOMMAND",
+		Short:   i18n.T("Manage the rollout of new pod specs"),
+		Long:    rolloutLong,
+		Example: rolloutExample,
+		Run:     cmdutil.DefaultSubCommandRun(errOut),
+	}
+	cmd.AddCommand(NewCmdRolloutReplace(f, out, errOut))
+	cmd.AddCommand(NewCmdRolloutUndo(f, out, errOut))
+	cmd.AddCommand(NewCmdRolloutHistory(f, out))
+	return cmd
+}
+
+// PodRollout replaces pods by new pods, and rolls the pods back when a new pod
+// is not ready in time
+type PodRollout struct {
+	Namespace string
+	Names     []string
+	Selector  string
+	MaxSurge  int
+	Timeout   time.Duration
+	DryRun    bool
+	Short     bool
+
+	PodClient coreclient.CoreInterface
+	Print     func(name, operation string)
+
+	// Template returns the template of the pod replacing pod, nil to keep pod
+	Template func(pod *api.Pod) (*api.PodTemplateSpec, error)
+
+	Out io.Writer
+	Err io.Writer
+}
+
+// rolloutStep is the replacement of a pod by a new pod
+type rolloutStep struct {
+	old *api.Pod
+	new *api.Pod
+}
+
+func addRolloutFlags(cmd *cobra.Command, o *PodRollout) {
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().IntVar(&o.MaxSurge, "max-surge", 1, "The number of new pods created and ready before the pods they replace are deleted. With 0, each pod is deleted before its new pod is created, which is required for pods with volumes.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", defaultRolloutTimeout, "The length of time to wait for each new pod to be ready before rolling back")
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringP("output", "o", "", "Output mode. Use \"-o name\" for shorter output (resource/name).")
+}
+
+// Complete completes the options shared by the rollout commands.
+func (o *PodRollout) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && len(o.Selector) == 0 {
+		return cmdutil.UsageErrorf(cmd, "one or more pods, or a selector, are required")
+	}
+	if len(args) > 0 && len(o.Selector) > 0 {
+		return cmdutil.UsageErrorf(cmd, "pods can't be given with a selector")
+	}
+	o.Names = args
+	if o.MaxSurge < 0 {
+		return cmdutil.UsageErrorf(cmd, "--max-surge must not be negative")
+	}
+	if o.Timeout <= 0 {
+		return cmdutil.UsageErrorf(cmd, "--timeout must be higher than zero")
+	}
+	o.DryRun = cmdutil.GetDryRunFlag(cmd)
+	output := cmdutil.GetFlagString(cmd, "output")
+	if len(output) > 0 && output != "name" {
+		return cmdutil.UsageErrorf(cmd, "unexpected -o output mode: %v, the only supported mode is -o name", output)
+	}
+	o.Short = output == "name"
+
+	namespace, _, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	clientset, err := f.ClientSet()
+	if err != nil {
+		return err
+	}
+	o.PodClient = clientset.Core()
+	mapper, _ := f.Object()
+	o.Print = func(name, operation string) {
+		f.PrintSuccess(mapper, o.Short, o.Out, "pods", name, o.DryRun, operation)
+	}
+	return nil
+}
+
+// Run plans the replacement of every pod, then rolls them out.
+func (o *PodRollout) Run() error {
+	pods, err := o.pods()
+	if err != nil {
+		return err
+	}
+	all, err := o.PodClient.Pods(o.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	taken := sets.NewString()
+	for _, pod := range all.Items {
+		taken.Insert(pod.Name)
+	}
+
+	steps := []rolloutStep{}
+	for _, pod := range pods {
+		template, err := o.Template(pod)
+		if err != nil {
+			return err
+		}
+		if template == nil {
+			o.Print(pod.Name, "unchanged")
+			continue
+		}
+		if o.MaxSurge > 0 {
+			for _, spec := range []*api.PodSpec{&pod.Spec, &template.Spec} {
+				if err := checkReplicaVolumes(spec); err != nil {
+					return fmt.Errorf("pod %s: %v while the pod it replaces runs, use --max-surge=0", pod.Name, err)
+				}
+			}
+		}
+		newPod, err := newRolloutPod(pod, template, taken)
+		if err != nil {
+			return err
+		}
+		steps = append(steps, rolloutStep{old: pod, new: newPod})
+	}
+
+	if o.DryRun {
+		for _, step := range steps {
+			o.Print(step.new.Name, "created")
+			o.Print(step.old.Name, "deleted")
+		}
+		return nil
+	}
+	return o.roll(steps)
+}
+
+func (o *PodRollout) pods() ([]*api.Pod, error) {
+	pods := []*api.Pod{}
+	if len(o.Selector) > 0 {
+		list, err := o.PodClient.Pods(o.Namespace).List(metav1.ListOptions{LabelSelector: o.Selector})
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			pods = append(pods, &list.Items[i])
+		}
+		if len(pods) == 0 {
+			return nil, fmt.Errorf("no pods found for selector %q", o.Selector)
+		}
+		sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+		return pods, nil
+	}
+	for _, name := range o.Names {
+		pod, err := o.PodClient.Pods(o.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
+
+// roll replaces the pods in batches of --max-surge pods. With --max-surge=0, each
+// pod is deleted before the new pod is created, so that its volumes are detached.
+func (o *PodRollout) roll(steps []rolloutStep) error {
+	batch := o.MaxSurge
+	if batch == 0 {
+		batch = 1
+	}
+	created, deleted := []*api.Pod{}, []*api.Pod{}
+	for start := 0; start < len(steps); start += batch {
+		end := start + batch
+		if end > len(steps) {
+			end = len(steps)
+		}
+		for _, step := range steps[start:end] {
+			if o.MaxSurge == 0 {
+				if err := o.deletePod(step.old); err != nil {
+					return o.rollback(created, deleted, err)
+				}
+				deleted = append(deleted, step.old)
+			}
+			if err := o.createPod(step.new); err != nil {
+				return o.rollback(created, deleted, err)
+			}
+			created = append(created, step.new)
+		}
+		for _, step := range steps[start:end] {
+			if err := waitForPodReady(o.PodClient, o.Namespace, step.new.Name, o.Timeout); err != nil {
+				return o.rollback(created, deleted, err)
+			}
+		}
+		if o.MaxSurge == 0 {
+			continue
+		}
+		for _, step := range steps[start:end] {
+			if err := o.deletePod(step.old); err != nil {
+				return o.rollback(created, deleted, err)
+			}
+			deleted = append(deleted, step.old)
+		}
+	}
+	return nil
+}
+
+// rollback deletes the new pods, and recreates the deleted pods with their
+// previous spec. With --max-surge above 0, the new pods are only deleted once
+// the previous pods are ready again.
+func (o *PodRollout) rollback(created, deleted []*api.Pod, cause error) error {
+	fmt.Fprintf(o.Err, "Rolling back the pods: %v\n", cause)
+	restore := func() error {
+		for _, pod := range deleted {
+			if err := o.createPod(copyReplica(pod, pod.Name, "")); err != nil {
+				return err
+			}
+		}
+		for _, pod := range deleted {
+			if err := waitForPodReady(o.PodClient, o.Namespace, pod.Name, o.Timeout); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	remove := func() error {
+		for _, pod := range created {
+			if err := o.deletePod(pod); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if o.MaxSurge > 0 {
+		if err = restore(); err == nil {
+			err = remove()
+		}
+	} else {
+		if err = remove(); err == nil {
+			err = restore()
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("rollout failed: %v, and the rollback failed: %v", cause, err)
+	}
+	return fmt.Errorf("rollout failed: %v, the pods were rolled back", cause)
+}
+
+func (o *PodRollout) createPod(pod *api.Pod) error {
+	if _, err := o.PodClient.Pods(o.Namespace).Create(pod); err != nil {
+		return err
+	}
+	o.Print(pod.Name, "created")
+	return nil
+}
+
+// deletePod deletes the pod and waits until it is gone
+func (o *PodRollout) deletePod(pod *api.Pod) error {
+	if err := o.PodClient.Pods(o.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+	o.Print(pod.Name, "deleted")
+	return waitForPodDeleted(o.PodClient, o.Namespace, pod.Name, o.Timeout)
+}
+
+// newRolloutPod returns the pod replacing old with the spec of template, at the
+// next revision, with the template of old recorded
+func newRolloutPod(old *api.Pod, template *api.PodTemplateSpec, taken sets.String) (*api.Pod, error) {
+	revision, err := pi.PodRevision(old.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("pod %s: %v", old.Name, err)
+	}
+	previous := &api.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{pi.RevisionAnnotation: strconv.FormatInt(revision, 10)},
+		},
+		Spec: *old.Spec.DeepCopy(),
+	}
+	previous.Spec.NodeName = ""
+	if changeCause, found := old.Annotations[pi.ChangeCauseAnnotation]; found {
+		previous.Annotations[pi.ChangeCauseAnnotation] = changeCause
+	}
+	encoded, err := encodePodTemplate(previous)
+	if err != nil {
+		return nil, err
+	}
+
+	name := rolloutPodName(old, revision+1, taken)
+	taken.Insert(name)
+	pod := copyReplica(old, name, "")
+	pod.Spec = *template.Spec.DeepCopy()
+	pod.Spec.NodeName = ""
+	delete(pod.Annotations, pi.ChangeCauseAnnotation)
+	if changeCause := template.Annotations[pi.ChangeCauseAnnotation]; len(changeCause) > 0 {
+		pod.Annotations[pi.ChangeCauseAnnotation] = changeCause
+	}
+	pod.Annotations[pi.RevisionAnnotation] = strconv.FormatInt(revision+1, 10)
+	pod.Annotations[pi.PreviousTemplateAnnotation] = encoded
+	return pod, nil
+}
+
+// rolloutPodName returns a free name for the pod replacing old. A replica of
+// 'pi run --replicas' gets the lowest free index of its replicas, other pods
+// get their name with a -r<revision> suffix.
+func rolloutPodName(old *api.Pod, revision int64, taken sets.String) string {
+	if set := old.Labels[replicaSetLabel]; len(set) > 0 {
+		if _, ok := replicaIndex(set, old.Name); ok {
+			for index := 0; ; index++ {
+				if name := replicaName(set, index); !taken.Has(name) {
+					return name
+				}
+			}
+		}
+	}
+	base := strings.TrimSuffix(old.Name, fmt.Sprintf("-r%d", revision-1))
+	for r := revision; ; r++ {
+		if name := fmt.Sprintf("%s-r%d", base, r); !taken.Has(name) {
+			return name
+		}
+	}
+}
+
+func encodePodTemplate(template *api.PodTemplateSpec) (string, error) {
+	external := &v1.PodTemplateSpec{}
+	if err := legacyscheme.Scheme.Convert(template, external, nil); err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(external)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// previousPodTemplate returns the template recorded by the rollout which created
+// the pod, nil if there is none
+func previousPodTemplate(pod *api.Pod) (*api.PodTemplateSpec, error) {
+	external, err := pi.PreviousPodTemplate(pod.Annotations)
+	if err != nil || external == nil {
+		return nil, err
+	}
+	template := &api.PodTemplateSpec{}
+	if err := legacyscheme.Scheme.Convert(external, template, nil); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	rolloutHistoryLong = templates.LongDesc(i18n.T(`
+		View the revision of pods, and the revision they replaced.`))
+
+	rolloutHistoryExample = templates.Examples(i18n.T(`
+		# View the revisions of the pods labeled app=web
+		pi rollout history -l app=web
+
+		# View the details of the revision 1 of the pod web-r2
+		pi rollout history web-r2 --revision=1`))
+)
+
+// NewCmdRolloutHistory creates a command to view the revisions of pods
+func NewCmdRolloutHistory(f cmdutil.Factory, out io.Writer) *cobra.Command {
+	options := &PodRollout{}
+	var revision int64
+	cmd := &cobra.Command{
+		Use:     "history (POD ... | -l selector) [--revision=REVISION]",
+		Short:   i18n.T("View the revisions of pods"),
+		Long:    rolloutHistoryLong,
+		Example: rolloutHistoryExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(RunRolloutHistory(f, out, cmd, args, options, revision))
+		},
+	}
+	cmd.Flags().StringVarP(&options.Selector, "selector", "l", "", "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().Int64Var(&revision, "revision", 0, "See the details, including podTemplate of the revision specified")
+	return cmd
+}
+
+// RunRolloutHistory prints the history of the pods with the HistoryViewer of pods
+func RunRolloutHistory(f cmdutil.Factory, out io.Writer, cmd *cobra.Command, args []string, options *PodRollout, revision int64) error {
+	if len(args) == 0 && len(options.Selector) == 0 {
+		return cmdutil.UsageErrorf(cmd, "one or more pods, or a selector, are required")
+	}
+	options.Names = args
+	namespace, _, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	options.Namespace = namespace
+	clientset, err := f.ClientSet()
+	if err != nil {
+		return err
+	}
+	options.PodClient = clientset.Core()
+	pods, err := options.pods()
+	if err != nil {
+		return err
+	}
+
+	mapper, _ := f.Object()
+	mapping, err := mapper.RESTMapping(schema.GroupKind{Kind: "Pod"})
+	if err != nil {
+		return err
+	}
+	viewer, err := f.HistoryViewer(mapping)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		history, err := viewer.ViewHistory(namespace, pod.Name, revision)
+		if err != nil {
+			return err
+		}
+		header := fmt.Sprintf("pods %q", pod.Name)
+		if revision > 0 {
+			header = fmt.Sprintf("%s with revision #%d", header, revision)
+		}
+		fmt.Fprintf(out, "%s\n%s\n", header, history)
+	}
+	return nil
+}
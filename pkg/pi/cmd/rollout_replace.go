@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/docker/distribution/reference"
+	"github.com/spf13/cobra"
+	api "k8s.io/kubernetes/pkg/apis/core"
+)
+
+var (
+	rolloutReplaceLong = templates.LongDesc(i18n.T(`
+		Replace pods by pods running a new image, one batch of --max-surge pods at a time.
+
+		Each new pod is created with the labels of the pod it replaces, in the same zone, and
+		the pod is deleted once the new pod is ready. The replicas of 'pi run --replicas' are
+		replaced by replicas with free indexes, other pods by pods named <pod>-r<revision>.
+
+		When a new pod is not ready within --timeout, the new pods are deleted and the pods
+		already replaced are recreated with their previous spec.`))
+
+	rolloutReplaceExample = templates.Examples(i18n.T(`
+		# Replace the pods labeled app=web with pods running nginx:1.15
+		pi rollout replace -l app=web --image=nginx:1.15
+
+		# Replace the pod db, which mounts a volume, deleting it before creating the new pod
+		pi rollout replace db --image=mysql:5.7 --max-surge=0
+
+		# Replace two pods at a time, and record the command in the new pods
+		pi rollout replace -l app=web --image=nginx:1.15 --max-surge=2 --record
+
+		# Show the pods that would be created and deleted
+		pi rollout replace -l app=web --image=nginx:1.15 --dry-run`))
+)
+
+// RolloutReplaceOptions declare the arguments accepted by the rollout replace command
+type RolloutReplaceOptions struct {
+	PodRollout
+
+	Container   string
+	Image       string
+	Record      bool
+	ChangeCause string
+}
+
+// NewCmdRolloutReplace creates a command to replace pods by pods with a new image
+func NewCmdRolloutReplace(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
+	options := &RolloutReplaceOptions{
+		PodRollout: PodRollout{
+			Out: out,
+			Err: errOut,
+		},
+	}
+	cmd := &cobra.Command{
+		Use:     "replace (POD ... | -l selector) --image=image [-c CONTAINER] [--max-surge=1] [--timeout=5m]",
+		Short:   i18n.T("Replace pods by pods running a new image"),
+		Long:    rolloutReplaceLong,
+		Example: rolloutReplaceExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	addRolloutFlags(cmd, &options.PodRollout)
+	cmd.Flags().StringVar(&options.Image, "image", "", i18n.T("The new image of the container"))
+	cmd.MarkFlagRequired("image")
+	cmd.Flags().StringVarP(&options.Container, "container", "c", "", "Container name. If omitted, the pods must have a single container")
+	cmdutil.AddRecordVarFlag(cmd, &options.Record)
+	return cmd
+}
+
+// Complete completes all the required options for rollout replace.
+func (o *RolloutReplaceOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(o.Image) == 0 {
+		return cmdutil.UsageErrorf(cmd, "--image is required")
+	}
+	if !reference.ReferenceRegexp.MatchString(o.Image) {
+		return fmt.Errorf("Invalid image name %q: %v", o.Image, reference.ErrReferenceInvalidFormat)
+	}
+	o.ChangeCause = f.Command(cmd, false)
+	o.Template = o.template
+	return o.PodRollout.Complete(f, cmd, args)
+}
+
+// template returns the spec of pod with the new image, nil if the image is unchanged
+func (o *RolloutReplaceOptions) template(pod *api.Pod) (*api.PodTemplateSpec, error) {
+	template := &api.PodTemplateSpec{Spec: *pod.Spec.DeepCopy()}
+	var container *api.Container
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == o.Container {
+			container = &template.Spec.Containers[i]
+		}
+	}
+	if len(o.Container) == 0 {
+		if len(template.Spec.Containers) != 1 {
+			return nil, fmt.Errorf("pod %s has %d containers, please specify one with -c", pod.Name, len(template.Spec.Containers))
+		}
+		container = &template.Spec.Containers[0]
+	}
+	if container == nil {
+		return nil, fmt.Errorf("container %s is not valid for pod %s", o.Container, pod.Name)
+	}
+	if container.Image == o.Image {
+		return nil, nil
+	}
+	container.Image = o.Image
+
+	if _, found := pod.Annotations[pi.ChangeCauseAnnotation]; o.Record || found {
+		template.Annotations = map[string]string{pi.ChangeCauseAnnotation: o.ChangeCause}
+	}
+	return template, nil
+}
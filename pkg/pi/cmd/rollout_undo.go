@@ -0,0 +1,77 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	api "k8s.io/kubernetes/pkg/apis/core"
+)
+
+var (
+	rolloutUndoLong = templates.LongDesc(i18n.T(`
+		Roll pods back to the spec of the pods they replaced.
+
+		The pods are replaced like with 'pi rollout replace', by pods with the template recorded
+		in their sh_hyper_previous_template annotation, at the next revision. The new pods
+		record the current template in turn, so undoing twice restores the current spec.`))
+
+	rolloutUndoExample = templates.Examples(i18n.T(`
+		# Roll the pods labeled app=web back to their previous spec
+		pi rollout undo -l app=web
+
+		# Roll the pod db back, deleting it before creating the new pod
+		pi rollout undo db-r2 --max-surge=0`))
+)
+
+// NewCmdRolloutUndo creates a command to roll pods back to their previous spec
+func NewCmdRolloutUndo(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
+	options := &PodRollout{
+		Out:      out,
+		Err:      errOut,
+		Template: previousRolloutTemplate,
+	}
+	cmd := &cobra.Command{
+		Use:     "undo (POD ... | -l selector) [--max-surge=1] [--timeout=5m]",
+		Short:   i18n.T("Roll pods back to their previous spec"),
+		Long:    rolloutUndoLong,
+		Example: rolloutUndoExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	addRolloutFlags(cmd, options)
+	return cmd
+}
+
+func previousRolloutTemplate(pod *api.Pod) (*api.PodTemplateSpec, error) {
+	template, err := previousPodTemplate(pod)
+	if err != nil {
+		return nil, fmt.Errorf("pod %s: %v", pod.Name, err)
+	}
+	if template == nil {
+		return nil, fmt.Errorf("pod %s has no previous revision, it was not created by 'pi rollout'", pod.Name)
+	}
+	return template, nil
+}
@@ -16,6 +16,7 @@ package cmd
 import (
 	"fmt"
 	"io"
+	"strings"
 	"time"
 
 	"github.com/hyperhq/pi/pkg/pi"
@@ -29,11 +30,11 @@ import (
 	"github.com/spf13/cobra"
 	batchv1 "k8s.io/api/batch/v1"
 	batchv1beta1 "k8s.io/api/batch/v1beta1"
-	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	api "k8s.io/kubernetes/pkg/apis/core"
 	coreclient "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset/typed/core/internalversion"
@@ -60,6 +61,9 @@ var (
 		# Start a single instance of nginx and set labels "app=nginx" and "env=prod" in the container.
 		pi run nginx --image=nginx --labels="app=nginx,env=prod"
 
+		# Start 3 replicas of nginx, nginx-0, nginx-1 and nginx-2, spread across the available zones.
+		pi run nginx --image=nginx --replicas=3
+
 		# Start a pod of busybox and keep it in the foreground, don't restart it if it exits.
 		pi run -it busybox --image=busybox --restart=Never -- sh
 
@@ -89,7 +93,7 @@ func NewCmdRun(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer) *co
 			cmdutil.CheckErr(err)
 		},
 	}
-	//cmdutil.AddPrinterFlags(cmd)
+	cmdutil.AddPrinterFlags(cmd)
 	AddRunFlags(cmd)
 	//cmdutil.AddApplyAnnotationFlags(cmd)
 	//cmdutil.AddRecordFlag(cmd)
@@ -99,14 +103,14 @@ func NewCmdRun(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer) *co
 }
 
 func AddRunFlags(cmd *cobra.Command) {
-	//cmdutil.AddDryRunFlag(cmd)
+	cmdutil.AddDryRunFlag(cmd)
 	cmdutil.AddPodRunningTimeoutFlag(cmd, defaultPodAttachTimeout)
 
 	cmd.Flags().String("generator", "", i18n.T("The name of the API generator to use, see http://kubernetes.io/docs/user-guide/pi-conventions/#generators for a list."))
 	cmd.Flags().String("image", "", i18n.T("The image for the container to run."))
 	cmd.MarkFlagRequired("image")
 	cmd.Flags().String("image-pull-policy", "", i18n.T("The image pull policy for the container. If left empty, this value will not be specified by the client and defaulted by the server"))
-	//cmd.Flags().IntP("replicas", "r", 1, "Number of replicas to create for this container. Default is 1.")
+	cmd.Flags().Int("replicas", 1, "Number of replicas to create for this container, named NAME-0 to NAME-<replicas-1> when greater than 1. Scale them with 'pi scale'. Default is 1.")
 	cmd.Flags().Bool("rm", false, "If true, delete resources created in this command for attached containers.")
 	//cmd.Flags().String("overrides", "", i18n.T("An inline JSON override for the generated object. If this is non-empty, it is used to override the generated object. Requires that the object supply a valid apiVersion field."))
 	cmd.Flags().StringArray("env", []string{}, "Environment variables to set in the container")
@@ -131,6 +135,7 @@ func AddRunFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP("active-deadline-seconds", "", "", i18n.T("Optional duration in seconds the pod may be active on the node relative to StartTime before the system will actively try to mark it failed and kill associated containers. Value must be a positive integer."))
 	cmd.Flags().StringP("size", "", "s4", i18n.T("The size for the pod (e.g. s1, s2, s3, s4, m1, m2, m3, l1, l2, l3, l4, l5, l6)"))
 	cmd.Flags().StringArray("volume", []string{}, "Pod volumes to mount into the container's filesystem. format '<volname>:<path>'")
+	cmd.Flags().String("security-group", "", i18n.T("The security groups of the pod, comma separated. Create them with 'pi create securitygroup'."))
 }
 
 func RunRun(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer, cmd *cobra.Command, args []string, argsLenAtDash int) error {
@@ -159,6 +164,9 @@ func RunRun(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer, cmd *c
 	if tty && !interactive {
 		return cmdutil.UsageErrorf(cmd, "-i/--stdin is required for containers with -t/--tty=true")
 	}
+	if interactive && cmdutil.GetDryRunFlag(cmd) {
+		return cmdutil.UsageErrorf(cmd, "--dry-run can't be used with attached containers options (--stdin or --tty)")
+	}
 
 	namespace, _, err := f.DefaultNamespace()
 	if err != nil {
@@ -203,17 +211,8 @@ func RunRun(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer, cmd *c
 	if len(generatorName) == 0 {
 		switch restartPolicy {
 		case api.RestartPolicyAlways:
-			// TODO: we need to deprecate this along with extensions/v1beta1.Deployments
-			// in favor of the new generator for apps/v1beta1.Deployments
-			hasResource, err := cmdutil.HasResource(clientset.Discovery(), extensionsv1beta1.SchemeGroupVersion.WithResource("deployments"))
-			if err != nil {
-				return err
-			}
-			if hasResource {
-				generatorName = cmdutil.DeploymentV1Beta1GeneratorName
-			} else {
-				generatorName = cmdutil.RunV1GeneratorName
-			}
+			// there are no deployments, a pod is always run, so the server is not
+			// asked which generator it supports and --dry-run=client works offline
 			generatorName = cmdutil.RunPodV1GeneratorName
 		case api.RestartPolicyOnFailure:
 			hasResource, err := cmdutil.HasResource(clientset.Discovery(), batchv1.SchemeGroupVersion.WithResource("jobs"))
@@ -277,6 +276,24 @@ func RunRun(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer, cmd *c
 	params["active-deadline-seconds"] = cmdutil.GetFlagString(cmd, "active-deadline-seconds")
 
 	params["volume"] = cmdutil.GetFlagStringArray(cmd, "volume")
+	params["security-group"] = cmdutil.GetFlagString(cmd, "security-group")
+
+	replicas := cmdutil.GetFlagInt(cmd, "replicas")
+	if replicas < 1 {
+		return cmdutil.UsageErrorf(cmd, "--replicas must be at least 1")
+	}
+	if replicas > 1 {
+		if generatorName != cmdutil.RunPodV1GeneratorName {
+			return cmdutil.UsageErrorf(cmd, "--replicas is only supported for pods, not with generator %q", generatorName)
+		}
+		if interactive {
+			return cmdutil.UsageErrorf(cmd, "--replicas can't be used with attached containers options (--stdin or --tty)")
+		}
+		if len(cmdutil.GetFlagStringArray(cmd, "volume")) > 0 {
+			return cmdutil.UsageErrorf(cmd, "--volume can't be used with --replicas, a volume can't be mounted by more than one replica")
+		}
+		return runReplicas(f, cmd, cmdOut, generator, names, params, namespace, replicas)
+	}
 
 	podClient := clientset.Core()
 	podName := params["name"].(string)
@@ -375,12 +392,68 @@ func RunRun(f cmdutil.Factory, cmdIn io.Reader, cmdOut, cmdErr io.Writer, cmd *c
 		options.PodClient = podClient
 		cmdutil.CheckErr(options.RunHyper(f))
 	} else {
-		f.PrintSuccess(runObject.Mapper, false, cmdOut, runObject.Mapping.Resource, args[0], false, "created")
+		return printRunObject(f, cmd, cmdOut, runObject, args[0])
 	}
 
 	return nil
 }
 
+// printRunObject prints the object created by run, or the object that would be
+// created with --dry-run=client when an output format is set
+func printRunObject(f cmdutil.Factory, cmd *cobra.Command, out io.Writer, runObject *RunObject, name string) error {
+	outputFormat := cmdutil.GetFlagString(cmd, "output")
+	if len(outputFormat) > 0 && outputFormat != "name" {
+		return f.PrintObject(cmd, false, runObject.Mapper, runObject.Object, out)
+	}
+	f.PrintSuccess(runObject.Mapper, outputFormat == "name", out, runObject.Mapping.Resource, name, cmdutil.GetDryRunFlag(cmd), "created")
+	return nil
+}
+
+// runReplicas creates the replicas NAME-0 to NAME-<replicas-1> of the generated pod,
+// with the label sh_hyper_replica_set=NAME, spread across the available zones. When a
+// replica can't be created, the error names the replicas created before it.
+func runReplicas(f cmdutil.Factory, cmd *cobra.Command, out io.Writer, generator pi.Generator, names []pi.GeneratorParam, params map[string]interface{}, namespace string, replicas int) error {
+	name := params["name"].(string)
+	labels, _ := params["labels"].(string)
+	if len(labels) == 0 {
+		labels = "run=" + name
+	}
+	labels += "," + replicaSetLabel + "=" + name
+
+	zones, err := availableZones(f)
+	if err != nil {
+		return err
+	}
+	spread := newZoneSpread(zones)
+	created := []string{}
+	for index := 0; index < replicas; index++ {
+		replicaParams := map[string]interface{}{}
+		for k, v := range params {
+			replicaParams[k] = v
+		}
+		replicaParams["name"] = replicaName(name, index)
+		replicaParams["labels"] = labels
+
+		overrides := ""
+		if zone := spread.next(); len(zone) > 0 {
+			overrides = fmt.Sprintf(`{"spec":{"nodeSelector":{"zone":%q}}}`, zone)
+		}
+		runObject, err := createGeneratedObject(f, cmd, generator, names, replicaParams, overrides, namespace)
+		if err != nil {
+			if len(created) == 0 || cmdutil.GetDryRunFlag(cmd) {
+				return err
+			}
+			return fmt.Errorf("could not create the replica %s: %v\ncreated %s, create the missing replicas with 'pi scale %s --replicas=%d' or delete the replicas with 'pi delete pods -l %s=%s'",
+				replicaName(name, index), err, strings.Join(created, ", "), name, replicas, replicaSetLabel, name)
+		}
+		created = append(created, replicaName(name, index))
+		if err := printRunObject(f, cmd, out, runObject, replicaName(name, index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func deletePod(podName string, podClient coreclient.CoreInterface) {
 	glog.V(4).Infof("deletel pod %v due to --rm", podName)
 	var gracePeriodSeconds int64 = 0
@@ -392,9 +465,16 @@ func deletePod(podName string, podClient coreclient.CoreInterface) {
 	}
 }
 
-// waitForPod watches the given pod until the exitCondition is true
-func waitForPod(podClient coreclient.PodsGetter, ns, name string, exitCondition watch.ConditionFunc) (*api.Pod, error) {
-	w, err := podClient.Pods(ns).Watch(metav1.SingleObject(metav1.ObjectMeta{Name: name}))
+// waitForPod watches the given pod until the exitCondition is true, or the timeout
+// is reached if not zero
+func waitForPod(podClient coreclient.PodsGetter, ns, name string, timeout time.Duration, exitCondition watch.ConditionFunc) (*api.Pod, error) {
+	return watchPod(podClient, ns, metav1.ObjectMeta{Name: name}, timeout, exitCondition)
+}
+
+// watchPod watches the pod of meta from its resource version if set, see waitForPod
+func watchPod(podClient coreclient.PodsGetter, ns string, meta metav1.ObjectMeta, timeout time.Duration, exitCondition watch.ConditionFunc) (*api.Pod, error) {
+	name := meta.Name
+	w, err := podClient.Pods(ns).Watch(metav1.SingleObject(meta))
 	if err != nil {
 		return nil, err
 	}
@@ -402,7 +482,7 @@ func waitForPod(podClient coreclient.PodsGetter, ns, name string, exitCondition
 	intr := interrupt.New(nil, w.Stop)
 	var result *api.Pod
 	err = intr.Run(func() error {
-		ev, err := watch.Until(0, w, func(ev watch.Event) (bool, error) {
+		ev, err := watch.Until(timeout, w, func(ev watch.Event) (bool, error) {
 			return exitCondition(ev)
 		})
 		if ev != nil {
@@ -419,8 +499,51 @@ func waitForPod(podClient coreclient.PodsGetter, ns, name string, exitCondition
 	return result, err
 }
 
+// waitForPodRunning waits until the pod is running, it fails if the pod completes
+// first or the timeout is reached
+func waitForPodRunning(podClient coreclient.PodsGetter, ns, name string, timeout time.Duration) error {
+	pod, err := waitForPod(podClient, ns, name, timeout, conditions.PodRunning)
+	switch err {
+	case conditions.ErrPodCompleted:
+		return fmt.Errorf("pod %s exited before the shell could start; current phase is %s", name, pod.Status.Phase)
+	case wait.ErrWaitTimeout:
+		return fmt.Errorf("wait for pod start timeout(%v seconds), please set --pod-running-timeout to the appropriate value", timeout.Seconds())
+	}
+	return err
+}
+
+// waitForPodReady waits until the pod is ready, and fails if it exits
+func waitForPodReady(podClient coreclient.PodsGetter, ns, name string, timeout time.Duration) error {
+	pod, err := waitForPod(podClient, ns, name, timeout, conditions.PodRunningAndReady)
+	switch err {
+	case conditions.ErrPodCompleted:
+		return fmt.Errorf("pod %s exited before it was ready; current phase is %s", name, pod.Status.Phase)
+	case wait.ErrWaitTimeout:
+		return fmt.Errorf("pod %s was not ready after %v, please set --timeout to the appropriate value", name, timeout)
+	}
+	return err
+}
+
+// waitForPodDeleted waits until the pod is deleted, the pod is watched from the
+// version read first so that a deletion meanwhile is not missed
+func waitForPodDeleted(podClient coreclient.PodsGetter, ns, name string, timeout time.Duration) error {
+	pod, err := podClient.Pods(ns).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	_, err = watchPod(podClient, ns, pod.ObjectMeta, timeout, func(ev watch.Event) (bool, error) {
+		return ev.Type == watch.Deleted, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("pod %s was not deleted after %v", name, timeout)
+	}
+	return err
+}
+
 func handleAttachPod(f cmdutil.Factory, podClient coreclient.PodsGetter, ns, name string, opts *AttachOptions) error {
-	pod, err := waitForPod(podClient, ns, name, conditions.PodRunningAndReady)
+	pod, err := waitForPod(podClient, ns, name, 0, conditions.PodRunningAndReady)
 	if err != nil && err != conditions.ErrPodCompleted {
 		return err
 	}
@@ -429,14 +552,17 @@ func handleAttachPod(f cmdutil.Factory, podClient coreclient.PodsGetter, ns, nam
 		return logOpts(f, pod, opts)
 	}
 
+	config, err := f.ClientConfig()
+	if err != nil {
+		return err
+	}
+	opts.Config = config
 	opts.PodClient = podClient
 	opts.PodName = name
 	opts.Namespace = ns
 
-	// TODO: opts.Run sets opts.Err to nil, we need to find a better way
-	stderr := opts.Err
-	if err := opts.Run(); err != nil {
-		fmt.Fprintf(stderr, "Error attaching, falling back to logs: %v\n", err)
+	if err := opts.RunHyper(); err != nil {
+		fmt.Fprintf(opts.Err, "Error attaching, falling back to logs: %v\n", err)
 		return logOpts(f, pod, opts)
 	}
 	return nil
@@ -545,9 +671,12 @@ func createGeneratedObject(f cmdutil.Factory, cmd *cobra.Command, generator pi.G
 		return nil, err
 	}
 
-	obj, err = resource.NewHelper(client, mapping).Create(namespace, false, info.Object)
-	if err != nil {
-		return nil, err
+	obj = info.Object
+	if !cmdutil.GetDryRunFlag(cmd) {
+		obj, err = resource.NewHelper(client, mapping).Create(namespace, false, info.Object)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &RunObject{
@@ -0,0 +1,360 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/kubernetes/pkg/apis/core"
+	coreclient "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset/typed/core/internalversion"
+)
+
+var (
+	scaleLong = templates.LongDesc(i18n.T(`
+		Set a new number of replicas for pods created with 'pi run NAME --replicas=N'.
+
+		The replicas of NAME are the pods NAME-0, NAME-1, ... with the label sh_hyper_replica_set=NAME.
+		New replicas are copies of an existing one, placed in the available zones reported by
+		'pi info' with the fewest replicas. Replicas are removed from the zones with the most
+		replicas first, highest index first.
+
+		Replicas can't mount flexVolumes, as a volume is only attached to one pod at a time.`))
+
+	scaleExample = templates.Examples(i18n.T(`
+		# Start 3 replicas of nginx, nginx-0, nginx-1 and nginx-2, spread across the zones
+		pi run nginx --image=nginx --replicas=3
+
+		# Scale nginx to 5 replicas
+		pi scale nginx --replicas=5
+
+		# Show which replicas would be removed when scaling nginx to 2 replicas
+		pi scale nginx --replicas=2 --dry-run
+
+		# Put the replicas of nginx behind a LoadBalancer service (x.x.x.x is fip)
+		pi create service loadbalancer nginx --tcp=80:80 -f=x.x.x.x -l=sh_hyper_replica_set=nginx`))
+)
+
+// replicaSetLabel is the label shared by the replicas of a pod, its value is the
+// name given to 'pi run --replicas'
+const replicaSetLabel = "sh_hyper_replica_set"
+
+// ScaleOptions declare the arguments accepted by the Scale command
+type ScaleOptions struct {
+	Namespace string
+	Name      string
+	Replicas  int
+	DryRun    bool
+	Short     bool
+
+	PodClient coreclient.CoreInterface
+	Zones     func() ([]string, error)
+	Print     func(name, operation string)
+
+	Out io.Writer
+}
+
+// NewCmdScale creates a command to scale the replicas of a pod
+func NewCmdScale(f cmdutil.Factory, out io.Writer) *cobra.Command {
+	options := &ScaleOptions{
+		Out: out,
+	}
+	cmd := &cobra.Command{
+		Use:     "scale NAME --replicas=COUNT [--dry-run]",
+		Short:   i18n.T("Set a new number of replicas for pods created by 'pi run --replicas'"),
+		Long:    scaleLong,
+		Example: scaleExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	cmd.Flags().IntVar(&options.Replicas, "replicas", -1, "The new number of replicas. Required.")
+	cmd.MarkFlagRequired("replicas")
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringP("output", "o", "", "Output mode. Use \"-o name\" for shorter output (resource/name).")
+	return cmd
+}
+
+// Complete completes all the required options for scale.
+func (o *ScaleOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "NAME is required for scale")
+	}
+	o.Name = args[0]
+	if o.Replicas < 1 {
+		return cmdutil.UsageErrorf(cmd, "--replicas must be at least 1, delete the replicas with 'pi delete pods -l %s=%s'", replicaSetLabel, o.Name)
+	}
+	o.DryRun = cmdutil.GetDryRunFlag(cmd)
+	output := cmdutil.GetFlagString(cmd, "output")
+	if len(output) > 0 && output != "name" {
+		return cmdutil.UsageErrorf(cmd, "unexpected -o output mode: %v, the only supported mode is -o name", output)
+	}
+	o.Short = output == "name"
+
+	namespace, _, err := f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	o.Namespace = namespace
+
+	clientset, err := f.ClientSet()
+	if err != nil {
+		return err
+	}
+	o.PodClient = clientset.Core()
+	o.Zones = func() ([]string, error) {
+		return availableZones(f)
+	}
+	mapper, _ := f.Object()
+	o.Print = func(name, operation string) {
+		f.PrintSuccess(mapper, o.Short, o.Out, "pods", name, o.DryRun, operation)
+	}
+	return nil
+}
+
+// Run adds or removes replicas until there are o.Replicas of them.
+func (o *ScaleOptions) Run() error {
+	list, err := o.PodClient.Pods(o.Namespace).List(metav1.ListOptions{
+		LabelSelector: replicaSetLabel + "=" + o.Name,
+	})
+	if err != nil {
+		return err
+	}
+	replicas := map[int]*api.Pod{}
+	for i := range list.Items {
+		if index, ok := replicaIndex(o.Name, list.Items[i].Name); ok {
+			replicas[index] = &list.Items[i]
+		}
+	}
+	if len(replicas) == 0 {
+		return fmt.Errorf("no replicas of %q found, create them with 'pi run %s --replicas=N'", o.Name, o.Name)
+	}
+
+	if o.Replicas < len(replicas) {
+		for _, pod := range replicasToRemove(replicas, len(replicas)-o.Replicas) {
+			if !o.DryRun {
+				if err := o.PodClient.Pods(o.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+					return err
+				}
+			}
+			o.Print(pod.Name, "deleted")
+		}
+		return nil
+	}
+	if o.Replicas == len(replicas) {
+		fmt.Fprintf(o.Out, "%s already has %d replica(s)\n", o.Name, o.Replicas)
+		return nil
+	}
+
+	template := replicas[lowestIndex(replicas)]
+	if err := checkReplicaVolumes(&template.Spec); err != nil {
+		return err
+	}
+	zones, err := o.Zones()
+	if err != nil {
+		return err
+	}
+	spread := newZoneSpread(zones)
+	for _, pod := range replicas {
+		spread.add(pod.Spec.NodeSelector["zone"])
+	}
+	for index := 0; len(replicas) < o.Replicas; index++ {
+		if _, found := replicas[index]; found {
+			continue
+		}
+		pod := copyReplica(template, replicaName(o.Name, index), spread.next())
+		if !o.DryRun {
+			if _, err := o.PodClient.Pods(o.Namespace).Create(pod); err != nil {
+				return err
+			}
+		}
+		replicas[index] = pod
+		o.Print(pod.Name, "created")
+	}
+	return nil
+}
+
+// replicaName returns the name of the replica of the given index
+func replicaName(name string, index int) string {
+	return fmt.Sprintf("%s-%d", name, index)
+}
+
+// replicaIndex returns the index of the replica podName of name
+func replicaIndex(name, podName string) (int, bool) {
+	if !strings.HasPrefix(podName, name+"-") {
+		return 0, false
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(podName, name+"-"))
+	if err != nil || index < 0 {
+		return 0, false
+	}
+	return index, true
+}
+
+func lowestIndex(replicas map[int]*api.Pod) int {
+	lowest := -1
+	for index := range replicas {
+		if lowest < 0 || index < lowest {
+			lowest = index
+		}
+	}
+	return lowest
+}
+
+// replicasToRemove picks count replicas, from the zones with the most replicas
+// first, and the highest index first within a zone
+func replicasToRemove(replicas map[int]*api.Pod, count int) []*api.Pod {
+	indexes := []int{}
+	perZone := map[string]int{}
+	for index, pod := range replicas {
+		indexes = append(indexes, index)
+		perZone[pod.Spec.NodeSelector["zone"]]++
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(indexes)))
+
+	removed := []*api.Pod{}
+	for len(removed) < count {
+		zone, most := "", -1
+		for _, index := range indexes {
+			z := replicas[index].Spec.NodeSelector["zone"]
+			if perZone[z] > most {
+				zone, most = z, perZone[z]
+			}
+		}
+		for i, index := range indexes {
+			if replicas[index].Spec.NodeSelector["zone"] == zone {
+				removed = append(removed, replicas[index])
+				indexes = append(indexes[:i], indexes[i+1:]...)
+				perZone[zone]--
+				break
+			}
+		}
+	}
+	return removed
+}
+
+// copyReplica returns a new replica with the labels, annotations and spec of
+// pod, in the given zone
+func copyReplica(pod *api.Pod, name, zone string) *api.Pod {
+	replica := &api.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		Spec: *pod.Spec.DeepCopy(),
+	}
+	for k, v := range pod.Labels {
+		replica.Labels[k] = v
+	}
+	for k, v := range pod.Annotations {
+		if k != api.LastAppliedConfigAnnotation {
+			replica.Annotations[k] = v
+		}
+	}
+	replica.Spec.NodeName = ""
+	if len(zone) > 0 {
+		if replica.Spec.NodeSelector == nil {
+			replica.Spec.NodeSelector = map[string]string{}
+		}
+		replica.Spec.NodeSelector["zone"] = zone
+	}
+	return replica
+}
+
+// checkReplicaVolumes returns an error if the pod mounts flexVolumes, which
+// can't be shared by its replicas
+func checkReplicaVolumes(spec *api.PodSpec) error {
+	for _, volume := range spec.Volumes {
+		if volume.FlexVolume != nil {
+			return fmt.Errorf("volume %q can't be mounted by more than one replica", volume.Name)
+		}
+	}
+	return nil
+}
+
+// availableZones returns the zones reported UP in the AvailabilityZone of 'pi info',
+// formatted like "gcp-us-central1-a|UP,gcp-us-central1-c|UP"
+func availableZones(f cmdutil.Factory) ([]string, error) {
+	cfg, err := f.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := hyper.NewConn(cfg)
+	if err != nil {
+		return nil, err
+	}
+	info, err := conn.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	zones := []string{}
+	for _, zone := range strings.Split(info["AvailabilityZone"], ",") {
+		parts := strings.SplitN(strings.TrimSpace(zone), "|", 2)
+		if len(parts[0]) == 0 || (len(parts) == 2 && !strings.EqualFold(parts[1], "UP")) {
+			continue
+		}
+		zones = append(zones, parts[0])
+	}
+	if len(zones) == 0 {
+		if zone := info["DefaultZone"]; len(zone) > 0 {
+			return []string{zone}, nil
+		}
+		return nil, fmt.Errorf("no available zone reported by 'pi info'")
+	}
+	return zones, nil
+}
+
+// zoneSpread places replicas in the zone with the fewest replicas, in the
+// order the zones are reported
+type zoneSpread struct {
+	zones []string
+	count map[string]int
+}
+
+func newZoneSpread(zones []string) *zoneSpread {
+	return &zoneSpread{zones: zones, count: map[string]int{}}
+}
+
+func (s *zoneSpread) add(zone string) {
+	s.count[zone]++
+}
+
+func (s *zoneSpread) next() string {
+	if len(s.zones) == 0 {
+		return ""
+	}
+	zone := s.zones[0]
+	for _, z := range s.zones[1:] {
+		if s.count[z] < s.count[zone] {
+			zone = z
+		}
+	}
+	s.add(zone)
+	return zone
+}
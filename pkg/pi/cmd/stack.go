@@ -0,0 +1,190 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+
+	"github.com/hyperhq/pi/pkg/pi"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/resource"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// A manifest may hold the volumes and fips of a stack, as the pseudo-kinds Volume
+// and FloatingIP:
+//
+//	apiVersion: v1
+//	kind: Volume
+//	metadata:
+//	  name: mysql-data
+//	spec:
+//	  size: 10
+//	  zone: gcp-us-central1-c
+//	  snapshot: snap1
+//	---
+//	apiVersion: v1
+//	kind: FloatingIP
+//	metadata:
+//	  name: wordpress
+//
+// A fip is allocated and named after the object. A LoadBalancer service may set
+// its loadBalancerIP to the name of a fip instead of the ip.
+
+// hyperResourceNames are the resource names printed for the pseudo-kinds
+var hyperResourceNames = map[string]string{
+	resource.VolumeKind:     "volume",
+	resource.FloatingIPKind: "fip",
+}
+
+// createHyperObject creates the volume or allocates and names the fip of info
+func createHyperObject(f cmdutil.Factory, info *resource.Info) error {
+	obj, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected object %T", info.Object)
+	}
+	if len(info.Name) == 0 {
+		return fmt.Errorf("%s has no name", info.Mapping.GroupVersionKind.Kind)
+	}
+
+	switch info.Mapping.GroupVersionKind.Kind {
+	case resource.VolumeKind:
+		volume := pi.VolumeCreateRequest{Name: info.Name}
+		if size, found := unstructured.NestedInt64(obj.Object, "spec", "size"); found {
+			volume.Size = int(size)
+		}
+		volume.Zone, _ = unstructured.NestedString(obj.Object, "spec", "zone")
+		volume.Snapshot, _ = unstructured.NestedString(obj.Object, "spec", "snapshot")
+		if volume.Size == 0 && volume.Snapshot == "" {
+			volume.Size = 10
+		}
+		volCli, err := newVolumeClient(f, ioutil.Discard)
+		if err != nil {
+			return err
+		}
+		_, err = volCli.create(volume)
+		return err
+	case resource.FloatingIPKind:
+		fipCli, err := newFipClient(f)
+		if err != nil {
+			return err
+		}
+		fip, err := fipCli.find(info.Name)
+		if err != nil {
+			return err
+		}
+		if fip != nil {
+			return fmt.Errorf("fip %q already exists: %v", info.Name, fip.Fip)
+		}
+		if fip, err = fipCli.allocate(); err != nil {
+			return err
+		}
+		if err := fipCli.name(fip.Fip, info.Name); err != nil {
+			// do not leak the fip, it could not be found by name
+			if releaseErr := fipCli.release(fip.Fip); releaseErr != nil {
+				return fmt.Errorf("%v, and releasing fip %s failed: %v", err, fip.Fip, releaseErr)
+			}
+			return err
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported kind %s", info.Mapping.GroupVersionKind.Kind)
+}
+
+// deleteHyperObject deletes the volume or releases the fip of info, it returns
+// false if the volume or the fip does not exist
+func deleteHyperObject(f cmdutil.Factory, info *resource.Info) (bool, error) {
+	obj, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return false, fmt.Errorf("unexpected object %T", info.Object)
+	}
+
+	switch info.Mapping.GroupVersionKind.Kind {
+	case resource.VolumeKind:
+		zone, _ := unstructured.NestedString(obj.Object, "spec", "zone")
+		volCli, err := newVolumeClient(f, ioutil.Discard)
+		if err != nil {
+			return false, err
+		}
+		volumes, err := volCli.list(zone)
+		if err != nil {
+			return false, err
+		}
+		for _, volume := range volumes {
+			if volume.Name == info.Name {
+				return true, volCli.delete(volume.Name, volume.Zone)
+			}
+		}
+		return false, nil
+	case resource.FloatingIPKind:
+		fipCli, err := newFipClient(f)
+		if err != nil {
+			return false, err
+		}
+		fip, err := fipCli.find(info.Name)
+		if err != nil || fip == nil {
+			return false, err
+		}
+		return true, fipCli.release(fip.Fip)
+	}
+	return false, fmt.Errorf("unsupported kind %s", info.Mapping.GroupVersionKind.Kind)
+}
+
+// resolveLoadBalancerFip replaces the loadBalancerIP of a service which is the
+// name of a fip with the ip of the fip
+func resolveLoadBalancerFip(f cmdutil.Factory, info *resource.Info) error {
+	obj, ok := info.Object.(*unstructured.Unstructured)
+	if !ok || obj.GetKind() != "Service" {
+		return nil
+	}
+	name, _ := unstructured.NestedString(obj.Object, "spec", "loadBalancerIP")
+	if len(name) == 0 || net.ParseIP(name) != nil {
+		return nil
+	}
+	fipCli, err := newFipClient(f)
+	if err != nil {
+		return err
+	}
+	fip, err := fipCli.find(name)
+	if err != nil {
+		return err
+	}
+	if fip == nil {
+		return fmt.Errorf("the loadBalancerIP %q is neither an ip nor the name of a fip", name)
+	}
+	unstructured.SetNestedField(obj.Object, fip.Fip, "spec", "loadBalancerIP")
+	return nil
+}
+
+// printHyperSuccess prints the operation on the volume or the fip of info, as
+// PrintSuccess does for the Kubernetes resources
+func printHyperSuccess(out io.Writer, shortOutput bool, info *resource.Info, dryRun bool, operation string) {
+	name := hyperResourceNames[info.Mapping.GroupVersionKind.Kind]
+	if shortOutput {
+		fmt.Fprintf(out, "%s/%s\n", name, info.Name)
+		return
+	}
+	dryRunMsg := ""
+	if dryRun {
+		dryRunMsg = " (dry run)"
+	}
+	fmt.Fprintf(out, "%s \"%s\" %s%s\n", name, info.Name, operation, dryRunMsg)
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	topLong = templates.LongDesc(i18n.T(`
+		Display Resource (CPU/Memory) usage.
+
+		The top command allows you to see the resource consumption of pods.`))
+)
+
+func NewCmdTop(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "top",
+		Short: i18n.T("Display Resource (CPU/Memory) usage."),
+		Long:  topLong,
+		Run:   cmdutil.DefaultSubCommandRun(errOut),
+	}
+
+	// create subcommands
+	cmd.AddCommand(NewCmdTopPod(f, out, errOut))
+	return cmd
+}
@@ -0,0 +1,199 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	restclient "github.com/hyperhq/client-go/rest"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/metricsutil"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+	"github.com/hyperhq/pi/pkg/pi/util/term"
+
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	api "k8s.io/kubernetes/pkg/apis/core"
+	coreclient "k8s.io/kubernetes/pkg/client/clientset_generated/internalclientset/typed/core/internalversion"
+)
+
+// TopPodOptions declare the arguments accepted by the top pod command
+type TopPodOptions struct {
+	ResourceName    string
+	Namespace       string
+	Selector        string
+	PrintContainers bool
+	Watch           bool
+	Interval        time.Duration
+
+	PodClient coreclient.PodsGetter
+	Config    *restclient.Config
+
+	Out io.Writer
+	Err io.Writer
+}
+
+var (
+	topPodLong = templates.LongDesc(i18n.T(`
+		Display Resource (CPU/Memory) usage of pods.
+
+		The usage is read from the stats of the running containers of the pods.
+		The CPU is averaged since the previous sample, the memory is the working set.
+
+		With --watch the usage is refreshed until interrupted.`))
+
+	topPodExample = templates.Examples(i18n.T(`
+		# Show metrics for all pods
+		pi top pod
+
+		# Show metrics for a given pod
+		pi top pod POD_NAME
+
+		# Show metrics for the pods defined by label name=myLabel
+		pi top pod -l name=myLabel
+
+		# Show metrics for the containers of the pods, refreshed every 5 seconds
+		pi top pod --containers --watch --interval=5s`))
+)
+
+func NewCmdTopPod(f cmdutil.Factory, cmdOut, cmdErr io.Writer) *cobra.Command {
+	options := &TopPodOptions{
+		Interval: 2 * time.Second,
+		Out:      cmdOut,
+		Err:      cmdErr,
+	}
+	cmd := &cobra.Command{
+		Use:     "pod [NAME | -l label]",
+		Short:   i18n.T("Display Resource (CPU/Memory) usage of pods"),
+		Long:    topPodLong,
+		Example: topPodExample,
+		Aliases: []string{"pods", "po"},
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Validate())
+			cmdutil.CheckErr(options.RunTopPod())
+		},
+	}
+	cmd.Flags().StringVarP(&options.Selector, "selector", "l", options.Selector, "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().BoolVar(&options.PrintContainers, "containers", options.PrintContainers, "If present, print usage of containers within a pod.")
+	cmd.Flags().BoolVarP(&options.Watch, "watch", "w", options.Watch, "After printing the usage, keep refreshing it until interrupted.")
+	cmd.Flags().DurationVar(&options.Interval, "interval", options.Interval, "The time between two refreshes, with --watch.")
+	return cmd
+}
+
+// Complete completes all the required options for top pod.
+func (o *TopPodOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	if len(args) == 1 {
+		o.ResourceName = args[0]
+	} else if len(args) > 1 {
+		return cmdutil.UsageErrorf(cmd, "%s", cmd.Use)
+	}
+
+	o.Namespace, _, err = f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	o.Config, err = f.ClientConfig()
+	if err != nil {
+		return err
+	}
+	clientset, err := f.ClientSet()
+	if err != nil {
+		return err
+	}
+	o.PodClient = clientset.Core()
+	return nil
+}
+
+// Validate checks the set of flags provided by the user.
+func (o *TopPodOptions) Validate() error {
+	if len(o.ResourceName) > 0 && len(o.Selector) > 0 {
+		return errors.New("only one of NAME or --selector can be provided")
+	}
+	if len(o.Selector) > 0 {
+		if _, err := labels.Parse(o.Selector); err != nil {
+			return err
+		}
+	}
+	if o.Interval <= 0 {
+		return errors.New("--interval must be greater than 0")
+	}
+	return nil
+}
+
+// RunTopPod prints the usage of the pods, once or until interrupted with --watch.
+func (o *TopPodOptions) RunTopPod() error {
+	cli, err := hyper.NewHyperCli(o.Config.Host, o.Config, nil, o.Out, o.Err)
+	if err != nil {
+		return err
+	}
+	statsClient := metricsutil.NewHyperStatsClient(cli.Client, o.Err)
+	printer := metricsutil.NewTopCmdPrinter(o.Out)
+
+	for first := true; ; first = false {
+		pods, err := o.getPods()
+		if err != nil {
+			return err
+		}
+		metrics, err := statsClient.GetPodMetrics(pods)
+		if err != nil {
+			return err
+		}
+
+		if o.Watch {
+			if term.IsTerminal(o.Out) {
+				// clear the screen, so the usage is refreshed in place
+				fmt.Fprint(o.Out, "\033[H\033[2J")
+			} else if !first {
+				fmt.Fprintln(o.Out)
+			}
+		}
+		if len(metrics) == 0 {
+			fmt.Fprintln(o.Out, "No resources found.")
+		} else if err := printer.PrintPodMetrics(metrics, o.PrintContainers, false); err != nil {
+			return err
+		}
+
+		if !o.Watch {
+			return nil
+		}
+		time.Sleep(o.Interval)
+	}
+}
+
+// getPods returns the named pod, or the pods matching the selector
+func (o *TopPodOptions) getPods() ([]api.Pod, error) {
+	if len(o.ResourceName) > 0 {
+		pod, err := o.PodClient.Pods(o.Namespace).Get(o.ResourceName, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []api.Pod{*pod}, nil
+	}
+	podList, err := o.PodClient.Pods(o.Namespace).List(metav1.ListOptions{LabelSelector: o.Selector})
+	if err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
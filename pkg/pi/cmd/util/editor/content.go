@@ -0,0 +1,102 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package editor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// StripComments removes the comment lines at the top of content, written by
+// pi edit. The lines of the object starting with a '#', like in a block
+// scalar, are kept.
+func StripComments(content []byte) []byte {
+	rest := content
+	for len(rest) > 0 {
+		line := rest
+		next := []byte{}
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, next = rest[:i], rest[i+1:]
+		}
+		if !bytes.HasPrefix(bytes.TrimSpace(line), []byte("#")) {
+			break
+		}
+		rest = next
+	}
+	return rest
+}
+
+// DecodeSecretData moves the values of the data of a secret which are valid UTF-8
+// to stringData, decoded
+func DecodeSecretData(secret []byte) ([]byte, error) {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(secret, &obj); err != nil {
+		return nil, err
+	}
+	data, _ := obj["data"].(map[string]interface{})
+	stringData := map[string]interface{}{}
+	for key, value := range data {
+		encoded, ok := value.(string)
+		if !ok {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || !utf8.Valid(decoded) {
+			continue
+		}
+		stringData[key] = string(decoded)
+		delete(data, key)
+	}
+	if len(stringData) == 0 {
+		return secret, nil
+	}
+	if len(data) == 0 {
+		delete(obj, "data")
+	}
+	obj["stringData"] = stringData
+	return json.Marshal(obj)
+}
+
+// EncodeSecretData moves the values of the stringData of a secret back to data,
+// base64 encoded
+func EncodeSecretData(secret []byte) ([]byte, error) {
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(secret, &obj); err != nil {
+		return nil, err
+	}
+	stringData, found := obj["stringData"].(map[string]interface{})
+	if !found {
+		return secret, nil
+	}
+	data, _ := obj["data"].(map[string]interface{})
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	for key, value := range stringData {
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("stringData.%s: the value must be a string", key)
+		}
+		data[key] = base64.StdEncoding.EncodeToString([]byte(s))
+	}
+	delete(obj, "stringData")
+	obj["data"] = data
+	return json.Marshal(obj)
+}
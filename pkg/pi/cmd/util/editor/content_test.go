@@ -0,0 +1,73 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package editor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ghodss/yaml"
+)
+
+func TestStripCommentsInDecodedSecret(t *testing.T) {
+	config := "[mysqld]\n# the default port\nport = 3306\n  # indented comment\n"
+	original := []byte(fmt.Sprintf(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"mysql"},"data":{"my.cnf":%q}}`,
+		base64.StdEncoding.EncodeToString([]byte(config))))
+
+	decoded, err := DecodeSecretData(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := yaml.JSONToYAML(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(content, []byte("# the default port")) {
+		t.Fatalf("expected the comment of the value in the edited content, got:\n%s", content)
+	}
+
+	for _, header := range []string{
+		"# Please edit the object below.\n#\n",
+		"# Please edit the object below.\n#\n# secrets \"mysql\" was not valid:\n# * invalid value\n#\n",
+	} {
+		stripped := StripComments(append([]byte(header), content...))
+		if !bytes.Equal(stripped, content) {
+			t.Errorf("expected the header to be stripped, got:\n%s", stripped)
+		}
+		modified, err := yaml.YAMLToJSON(stripped)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if modified, err = EncodeSecretData(modified); err != nil {
+			t.Fatal(err)
+		}
+		var objOriginal, objModified interface{}
+		if err := json.Unmarshal(original, &objOriginal); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(modified, &objModified); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(objOriginal, objModified) {
+			t.Errorf("expected the secret to be unchanged, got %s", modified)
+		}
+	}
+}
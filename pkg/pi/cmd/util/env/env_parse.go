@@ -130,6 +130,11 @@ func ParseEnv(spec []string, defaultReader io.Reader) ([]v1.EnvVar, []string, er
 	return parseIntoEnvVar(spec, defaultReader, "environment variable")
 }
 
+// ReadEnv reads the key=value lines of an env file, the text after a # is ignored.
+func ReadEnv(r io.Reader) ([]v1.EnvVar, error) {
+	return readEnv(r, "environment variable")
+}
+
 func readEnv(r io.Reader, envVarType string) ([]v1.EnvVar, error) {
 	env := []v1.EnvVar{}
 	scanner := bufio.NewScanner(r)
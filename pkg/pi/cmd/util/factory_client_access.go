@@ -33,8 +33,10 @@ import (
 	"github.com/hyperhq/client-go/kubernetes"
 	restclient "github.com/hyperhq/client-go/rest"
 	"github.com/hyperhq/client-go/tools/clientcmd"
+	clientcmdapi "github.com/hyperhq/client-go/tools/clientcmd/api"
 	"github.com/hyperhq/client-go/util/homedir"
 	"github.com/hyperhq/pi/pkg/pi"
+	"github.com/hyperhq/pi/pkg/pi/credentials"
 	"github.com/hyperhq/pi/pkg/pi/resource"
 	"github.com/hyperhq/pi/pkg/printers"
 	printersinternal "github.com/hyperhq/pi/pkg/printers/internalversion"
@@ -179,7 +181,96 @@ func DefaultClientConfig(flags *pflag.FlagSet) clientcmd.ClientConfig {
 	clientcmd.BindOverrideFlags(overrides, flags, flagNames)
 	clientConfig := clientcmd.NewInteractiveDeferredLoadingClientConfig(loadingRules, overrides, os.Stdin)
 
-	return clientConfig
+	return &credentialClientConfig{delegate: clientConfig, overrides: overrides}
+}
+
+// credentialClientConfig reads the keys of the users which reference a credential
+// store, see credentials.AuthProviderName, instead of holding the keys
+type credentialClientConfig struct {
+	delegate  clientcmd.ClientConfig
+	overrides *clientcmd.ConfigOverrides
+}
+
+func (c *credentialClientConfig) RawConfig() (clientcmdapi.Config, error) {
+	return c.delegate.RawConfig()
+}
+
+func (c *credentialClientConfig) Namespace() (string, bool, error) {
+	return c.delegate.Namespace()
+}
+
+func (c *credentialClientConfig) ConfigAccess() clientcmd.ConfigAccess {
+	return c.delegate.ConfigAccess()
+}
+
+func (c *credentialClientConfig) ClientConfig() (*restclient.Config, error) {
+	if err := c.loadCredential(); err != nil {
+		return nil, err
+	}
+	config, err := c.delegate.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	// the keys are read, there is no auth provider plugin to run
+	if config.AuthProvider != nil && config.AuthProvider.Name == credentials.AuthProviderName {
+		config.AuthProvider = nil
+		config.AuthConfigPersister = nil
+	}
+	// client-go sets the insecure mode whatever the cluster entry, the server
+	// certificate is verified unless insecure-skip-tls-verify is set
+	rawConfig, err := c.RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	config.Insecure = c.overrides.ClusterInfo.InsecureSkipTLSVerify
+	if cluster, found := rawConfig.Clusters[c.currentContext(rawConfig).Cluster]; found && cluster.InsecureSkipTLSVerify {
+		config.Insecure = true
+	}
+	return config, nil
+}
+
+// currentContext returns the context of the config selected by the overrides,
+// with the cluster and the user set on the command line
+func (c *credentialClientConfig) currentContext(rawConfig clientcmdapi.Config) clientcmdapi.Context {
+	contextName := rawConfig.CurrentContext
+	if len(c.overrides.CurrentContext) > 0 {
+		contextName = c.overrides.CurrentContext
+	}
+	context := clientcmdapi.Context{}
+	if found, ok := rawConfig.Contexts[contextName]; ok {
+		context = *found
+	}
+	if len(c.overrides.Context.Cluster) > 0 {
+		context.Cluster = c.overrides.Context.Cluster
+	}
+	if len(c.overrides.Context.AuthInfo) > 0 {
+		context.AuthInfo = c.overrides.Context.AuthInfo
+	}
+	return context
+}
+
+// loadCredential reads the keys of the user of the current context from its
+// credential store into the overrides, as if they were set with --access-key and
+// --secret-key. The keys set on the command line are kept.
+func (c *credentialClientConfig) loadCredential() error {
+	if len(c.overrides.AuthInfo.AccessKey) > 0 || len(c.overrides.AuthInfo.SecretKey) > 0 {
+		return nil
+	}
+	rawConfig, err := c.RawConfig()
+	if err != nil {
+		return err
+	}
+	authInfoName := c.currentContext(rawConfig).AuthInfo
+	authInfo, found := rawConfig.AuthInfos[authInfoName]
+	if !found || len(credentials.StoreName(authInfo)) == 0 {
+		return nil
+	}
+	accessKey, secretKey, err := credentials.LoadCredential(authInfoName, *authInfo)
+	if err != nil {
+		return err
+	}
+	c.overrides.AuthInfo.AccessKey, c.overrides.AuthInfo.SecretKey = accessKey, secretKey
+	return nil
 }
 
 func (f *ring0Factory) DiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
@@ -502,6 +593,7 @@ const (
 	HyperVolumeV1GeneratorName = "hyper-volume/v1"
 	HyperFipV1GeneratorName    = "hyper-fip/v1"
 	HyperFipV1RenameName       = "hyper-rename-fip/v1"
+	HyperCronV1GeneratorName   = "hyper-cron/v1"
 )
 
 // DefaultGenerators returns the set of default generators for use in Factory instances
@@ -552,6 +644,10 @@ func DefaultGenerators(cmdName string) map[string]pi.Generator {
 		generator = map[string]pi.Generator{
 			JobV1GeneratorName: pi.JobV1{},
 		}
+	case "cronjob":
+		generator = map[string]pi.Generator{
+			HyperCronV1GeneratorName: pi.CronGeneratorV1{},
+		}
 	}
 
 	return generator
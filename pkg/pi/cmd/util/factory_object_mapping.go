@@ -393,10 +393,9 @@ func (f *ring1Factory) Validator(validate bool) (validation.Schema, error) {
 
 // OpenAPISchema returns metadata and structural information about Kubernetes object definitions.
 func (f *ring1Factory) OpenAPISchema() (openapi.Resources, error) {
-	discovery, err := f.clientAccessFactory.DiscoveryClient()
-	if err != nil {
-		return nil, err
-	}
+	// The schema is built into the binary, so it is still available
+	// when there is no configuration to reach the server
+	discovery, _ := f.clientAccessFactory.DiscoveryClient()
 
 	// Lazily initialize the OpenAPIGetter once
 	f.openAPIGetter.once.Do(func() {
@@ -29,6 +29,7 @@ import (
 
 	"github.com/hyperhq/client-go/tools/clientcmd"
 	"github.com/hyperhq/pi/pkg/pi"
+	"github.com/hyperhq/pi/pkg/pi/cmd/util/env"
 	"github.com/hyperhq/pi/pkg/pi/resource"
 	"github.com/hyperhq/pi/pkg/printers"
 
@@ -409,9 +410,85 @@ func AddFilenameOptionFlags(cmd *cobra.Command, options *resource.FilenameOption
 	//cmd.Flags().BoolVarP(&options.Recursive, "recursive", "R", options.Recursive, "Process the directory used in -f, --filename recursively. Useful when you want to manage related manifests organized within the same directory.")
 }
 
+// AddTemplateParamFlags adds the flags setting the values of the template references
+// of the files given with --filename
+func AddTemplateParamFlags(cmd *cobra.Command) {
+	cmd.Flags().StringArray("param", []string{}, "Value of the $NAME or ${NAME} references in the files, as NAME=value. The files are expanded as templates when a param or a param file is set, the *.tpl.yaml, *.tpl.yml and *.tpl.json files always are.")
+	cmd.Flags().StringArray("param-file", []string{}, "Path to a file of NAME=value lines, the values of the references in the files. A --param overrides the value of a param file.")
+}
+
+// GetTemplateParams returns the params of --param-file and --param, or nil if none is set
+func GetTemplateParams(cmd *cobra.Command) (map[string]string, error) {
+	paramFiles := GetFlagStringArray(cmd, "param-file")
+	paramSpecs := GetFlagStringArray(cmd, "param")
+	if len(paramFiles) == 0 && len(paramSpecs) == 0 {
+		return nil, nil
+	}
+
+	params := map[string]string{}
+	for _, path := range paramFiles {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		vars, err := env.ReadEnv(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", path, err)
+		}
+		for _, v := range vars {
+			if !env.IsValidEnvironmentArgument(v.Name + "=") {
+				return nil, fmt.Errorf("invalid param %q in %s, params must be of the form NAME=value", v.Name, path)
+			}
+			params[v.Name] = v.Value
+		}
+	}
+	for _, spec := range paramSpecs {
+		if !env.IsValidEnvironmentArgument(spec) {
+			return nil, UsageErrorf(cmd, "invalid param %q, params must be of the form NAME=value", spec)
+		}
+		parts := strings.SplitN(spec, "=", 2)
+		params[parts[0]] = parts[1]
+	}
+	return params, nil
+}
+
+const (
+	// DryRunNone sends the object to the server
+	DryRunNone = "none"
+	// DryRunClient only prints the object that would be sent
+	DryRunClient = "client"
+)
+
+// dryRunValue is the value of the dry-run flag. The true and false values of the
+// former bool flag are still accepted, as client and none.
+type dryRunValue string
+
+func (v *dryRunValue) String() string {
+	return string(*v)
+}
+
+func (v *dryRunValue) Set(s string) error {
+	switch s {
+	case DryRunNone, "false":
+		*v = DryRunNone
+	case DryRunClient, "true":
+		*v = DryRunClient
+	default:
+		return fmt.Errorf("must be %q or %q, not %q", DryRunNone, DryRunClient, s)
+	}
+	return nil
+}
+
+func (v *dryRunValue) Type() string {
+	return "string"
+}
+
 // AddDryRunFlag adds dry-run flag to a command. Usually used by mutations.
 func AddDryRunFlag(cmd *cobra.Command) {
-	cmd.Flags().Bool("dry-run", false, "If true, only print the object that would be sent, without sending it.")
+	value := dryRunValue(DryRunNone)
+	cmd.Flags().Var(&value, "dry-run", `Must be "none" or "client". If client, only print the object that would be sent, without sending it.`)
+	cmd.Flags().Lookup("dry-run").NoOptDefVal = DryRunClient
 }
 
 func AddIncludeUninitializedFlag(cmd *cobra.Command) {
@@ -526,8 +603,13 @@ func GetRecordFlag(cmd *cobra.Command) bool {
 	return GetFlagBool(cmd, "record")
 }
 
+// GetDryRunFlag returns true if --dry-run=client is set
 func GetDryRunFlag(cmd *cobra.Command) bool {
-	return GetFlagBool(cmd, "dry-run")
+	flag := cmd.Flags().Lookup("dry-run")
+	if flag == nil {
+		glog.Fatalf("error accessing flag dry-run for command %s: flag not defined", cmd.Name())
+	}
+	return flag.Value.String() == DryRunClient
 }
 
 // RecordChangeCause annotate change-cause to input runtime object.
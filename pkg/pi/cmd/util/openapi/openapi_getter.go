@@ -20,7 +20,6 @@ import (
 	"sync"
 
 	"github.com/hyperhq/client-go/discovery"
-	"github.com/googleapis/gnostic/OpenAPIv2"
 )
 
 // synchronizedOpenAPIGetter fetches the openapi schema once and then caches it in memory
@@ -52,8 +51,12 @@ func NewOpenAPIGetter(openAPIClient discovery.OpenAPISchemaInterface) Getter {
 // Resources implements Getter
 func (g *synchronizedOpenAPIGetter) Get() (Resources, error) {
 	g.Do(func() {
-		//s, err := g.openAPIClient.OpenAPISchema()
-		s := &openapi_v2.Document{}
+		// Pi does not serve its openapi spec, use the schema built into the binary
+		s, err := NewPiOpenAPISchema()
+		if err != nil {
+			g.err = err
+			return
+		}
 		g.openAPISchema, g.err = NewOpenAPIData(s)
 	})
 
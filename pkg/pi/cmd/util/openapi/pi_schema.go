@@ -0,0 +1,294 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/googleapis/gnostic/OpenAPIv2"
+	"github.com/googleapis/gnostic/compiler"
+	yaml "gopkg.in/yaml.v2"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// piKinds are the top level objects served by Pi. Only these kinds, and the
+// types reachable from them, are part of the embedded schema.
+var piKinds = map[schema.GroupVersionKind]interface{}{
+	v1.SchemeGroupVersion.WithKind("Pod"):          v1.Pod{},
+	v1.SchemeGroupVersion.WithKind("Service"):      v1.Service{},
+	v1.SchemeGroupVersion.WithKind("Secret"):       v1.Secret{},
+	batchv1.SchemeGroupVersion.WithKind("Job"):     batchv1.Job{},
+	v1.SchemeGroupVersion.WithKind("PodList"):      v1.PodList{},
+	v1.SchemeGroupVersion.WithKind("ServiceList"):  v1.ServiceList{},
+	v1.SchemeGroupVersion.WithKind("SecretList"):   v1.SecretList{},
+	batchv1.SchemeGroupVersion.WithKind("JobList"): batchv1.JobList{},
+}
+
+// piUnsupportedFields are the fields of the upstream types that Pi rejects or
+// ignores, keyed by model name. They are left out of the embedded schema.
+var piUnsupportedFields = map[string][]string{
+	"io.k8s.api.core.v1.PodSpec": {
+		"hostNetwork", "hostPID", "hostIPC", "hostAliases", "priority", "priorityClassName",
+		"schedulerName", "serviceAccount", "serviceAccountName", "automountServiceAccountToken",
+	},
+	"io.k8s.api.core.v1.Volume": {
+		"hostPath", "gcePersistentDisk", "awsElasticBlockStore", "nfs", "iscsi", "glusterfs",
+		"persistentVolumeClaim", "rbd", "cinder", "cephfs", "flocker", "downwardAPI", "fc",
+		"azureFile", "configMap", "vsphereVolume", "quobyte", "azureDisk", "photonPersistentDisk",
+		"projected", "portworxVolume", "scaleIO", "storageos",
+	},
+}
+
+// piOptionalFields are the fields required upstream that Pi does not need, keyed
+// by model name.
+var piOptionalFields = map[string][]string{
+	"io.k8s.api.core.v1.FlexVolumeSource": {"driver"},
+}
+
+// piDescriptions documents the Pi specific behaviour of some fields. The text is
+// appended to the upstream description, keyed by model name and field name.
+var piDescriptions = map[string]map[string]string{
+	"io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+		"labels": "Pi recognizes the following labels. " +
+			"sh_hyper_sgroup_<name>: set to \"yes\" to add the pod to the security group <name>, see 'pi create securitygroup'.",
+		"annotations": "Pi recognizes the following annotations. " +
+			"sh_hyper_instancetype: the instance type of a pod or job pod (one of s1, s2, s3, s4, m1, m2, m3, l1, l2, l3, l4, l5, l6), defaults to s4. " +
+			"zone: the availability zone the pod is running in. It is set by the server, use spec.nodeSelector to choose a zone.",
+	},
+	"io.k8s.api.core.v1.PodSpec": {
+		"nodeSelector": "Pi only honours the 'zone' key, which places the pod in the given availability zone. " +
+			"Run 'pi info' to list the available zones. Volumes used by the pod must be in the same zone.",
+		"volumes": "Pi supports emptyDir, secret, gitRepo and flexVolume volumes.",
+	},
+	"io.k8s.api.core.v1.Volume": {
+		"flexVolume": "Pi uses flexVolume to attach a Pi volume: set options.volumeID to the name of a volume created with 'pi create volume'.",
+	},
+}
+
+// NewPiOpenAPISchema returns the openapi document describing the objects
+// served by Pi. It is built from the versioned API types compiled into the
+// binary, so it is available without contacting the server.
+func NewPiOpenAPISchema() (*openapi_v2.Document, error) {
+	b := &schemaBuilder{definitions: map[string]interface{}{}}
+	for gvk, obj := range piKinds {
+		name := b.addType(reflect.TypeOf(obj))
+		gvkExtension := map[string]string{"group": gvk.Group, "version": gvk.Version, "kind": gvk.Kind}
+		b.definitions[name].(map[string]interface{})[groupVersionKindExtensionKey] = []interface{}{gvkExtension}
+	}
+
+	spec, err := json.Marshal(map[string]interface{}{
+		"swagger":     "2.0",
+		"info":        map[string]string{"title": "Pi", "version": "v1"},
+		"paths":       map[string]interface{}{},
+		"definitions": b.definitions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var info yaml.MapSlice
+	if err := yaml.Unmarshal(spec, &info); err != nil {
+		return nil, err
+	}
+	return openapi_v2.NewDocument(info, compiler.NewContext("$root", nil))
+}
+
+var (
+	timeType        = reflect.TypeOf(metav1.Time{})
+	quantityType    = reflect.TypeOf(resource.Quantity{})
+	intOrStringType = reflect.TypeOf(intstr.IntOrString{})
+)
+
+// schemaBuilder converts Go types into openapi definitions.
+type schemaBuilder struct {
+	definitions map[string]interface{}
+}
+
+// modelName returns the openapi model name of a named type, e.g.
+// io.k8s.api.core.v1.Pod for k8s.io/api/core/v1.Pod.
+func modelName(t reflect.Type) string {
+	pkgPath := t.PkgPath()
+	if i := strings.LastIndex(pkgPath, "/vendor/"); i >= 0 {
+		pkgPath = pkgPath[i+len("/vendor/"):]
+	}
+	path := strings.Split(pkgPath, "/")
+	domain := strings.Split(path[0], ".")
+	for i, j := 0, len(domain)-1; i < j; i, j = i+1, j-1 {
+		domain[i], domain[j] = domain[j], domain[i]
+	}
+	return strings.Join(append(append(domain, path[1:]...), t.Name()), ".")
+}
+
+// addType adds the definition of the struct type t, and of every type it
+// references, and returns its model name.
+func (b *schemaBuilder) addType(t reflect.Type) string {
+	name := modelName(t)
+	if _, found := b.definitions[name]; found {
+		return name
+	}
+	definition := map[string]interface{}{}
+	b.definitions[name] = definition
+
+	switch t {
+	case timeType:
+		definition["type"] = "string"
+		definition["format"] = "date-time"
+		return name
+	case quantityType, intOrStringType:
+		definition["type"] = "string"
+		return name
+	}
+
+	docs := swaggerDoc(t)
+	if doc := docs[""]; len(doc) > 0 {
+		definition["description"] = doc
+	}
+
+	unsupported := map[string]bool{}
+	for _, field := range piUnsupportedFields[name] {
+		unsupported[field] = true
+	}
+
+	properties := map[string]interface{}{}
+	required := []string{}
+	b.addFields(t, name, docs, unsupported, properties, &required)
+	definition["properties"] = properties
+	if len(required) > 0 {
+		definition["required"] = required
+	}
+	return name
+}
+
+// addFields adds the serialized fields of t to properties, descending into
+// inlined structs.
+func (b *schemaBuilder) addFields(t reflect.Type, name string, docs map[string]string, unsupported map[string]bool, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		if tag[0] == "-" || len(field.PkgPath) > 0 {
+			continue
+		}
+		if field.Anonymous && (len(tag[0]) == 0 || hasOption(tag, "inline")) {
+			inlineDocs := swaggerDoc(field.Type)
+			delete(inlineDocs, "")
+			for k, v := range docs {
+				inlineDocs[k] = v
+			}
+			b.addFields(field.Type, name, inlineDocs, unsupported, properties, required)
+			continue
+		}
+		fieldName := tag[0]
+		if len(fieldName) == 0 {
+			fieldName = field.Name
+		}
+		if unsupported[fieldName] {
+			continue
+		}
+
+		property := b.schemaFor(field.Type)
+		description := docs[fieldName]
+		if extra, found := piDescriptions[name][fieldName]; found {
+			if len(description) > 0 {
+				description += " "
+			}
+			description += extra
+		}
+		if len(description) > 0 {
+			property["description"] = description
+		}
+		if strategy := field.Tag.Get("patchStrategy"); len(strategy) > 0 {
+			property["x-kubernetes-patch-strategy"] = strategy
+		}
+		if mergeKey := field.Tag.Get("patchMergeKey"); len(mergeKey) > 0 {
+			property["x-kubernetes-patch-merge-key"] = mergeKey
+		}
+		properties[fieldName] = property
+		if !hasOption(tag, "omitempty") && !isOptional(name, fieldName) {
+			*required = append(*required, fieldName)
+		}
+	}
+}
+
+// schemaFor returns the openapi schema of a field of type t.
+func (b *schemaBuilder) schemaFor(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return map[string]interface{}{"$ref": "#/definitions/" + b.addType(t)}
+	}
+	return map[string]interface{}{"type": "object"}
+}
+
+// swaggerDoc returns the field documentation generated for t, keyed by json
+// field name. The documentation of the type itself is stored under "".
+func swaggerDoc(t reflect.Type) map[string]string {
+	docs := map[string]string{}
+	documented, ok := reflect.Zero(t).Interface().(interface {
+		SwaggerDoc() map[string]string
+	})
+	if !ok {
+		return docs
+	}
+	for k, v := range documented.SwaggerDoc() {
+		docs[k] = v
+	}
+	return docs
+}
+
+func isOptional(name, fieldName string) bool {
+	for _, field := range piOptionalFields[name] {
+		if field == fieldName {
+			return true
+		}
+	}
+	return false
+}
+
+func hasOption(tag []string, option string) bool {
+	for _, o := range tag[1:] {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
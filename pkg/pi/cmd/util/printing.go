@@ -224,5 +224,18 @@ func ValidNameResourceTypeList(f ClientAccessFactory) string {
 	return templates.LongDesc(`Valid resource types include:
 
 			* fip
+			* volume
+	`)
+}
+
+func ValidExplainResourceTypeList(f ClientAccessFactory) string {
+	// TODO: Should attempt to use the cached discovery list or fallback to a static list
+	// that is calculated from code compiled into the factory.
+	return templates.LongDesc(`Valid resource types include:
+
+			* pods (aka 'po')
+			* jobs
+			* secrets
+			* services (aka 'svc')
 	`)
 }
@@ -0,0 +1,178 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	hyperclient "github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+// volumeClient calls the volume endpoints of the Hyper API through a hyper.Conn,
+// so that a failed step can be rolled back
+type volumeClient struct {
+	conn *hyper.Conn
+	cli  *hyper.HyperCli
+	out  io.Writer
+}
+
+func newVolumeClient(f cmdutil.Factory, out io.Writer) (*volumeClient, error) {
+	cfg, err := f.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	cli, err := hyper.NewHyperCli(cfg.Host, cfg, nil, out, out)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := hyper.NewConn(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &volumeClient{conn: conn, cli: cli, out: out}, nil
+}
+
+// list returns the volumes of zone, or of every zone if zone is empty
+func (c *volumeClient) list(zone string) ([]hyperclient.VolumeResponse, error) {
+	volumes, err := c.conn.ListVolumes(zone)
+	if err != nil {
+		return nil, fmt.Errorf("error listing volumes: %v", err)
+	}
+	return volumes, nil
+}
+
+// get returns the volume name of zone, or of any zone if zone is empty. It fails if
+// there is no such volume, or if there are volumes of that name in several zones.
+func (c *volumeClient) get(name, zone string) (*hyperclient.VolumeResponse, error) {
+	volumes, err := c.list(zone)
+	if err != nil {
+		return nil, err
+	}
+	var found *hyperclient.VolumeResponse
+	for i := range volumes {
+		if volumes[i].Name != name || (zone != "" && volumes[i].Zone != zone) {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("volume %q exists in zones %s and %s, set the zone of the volume", name, found.Zone, volumes[i].Zone)
+		}
+		found = &volumes[i]
+	}
+	if found == nil {
+		return nil, fmt.Errorf("volume %q not found", name)
+	}
+	return found, nil
+}
+
+// create creates the volume, from a snapshot if the snapshot of volume is set
+func (c *volumeClient) create(volume pi.VolumeCreateRequest) (*hyperclient.VolumeResponse, error) {
+	created, err := c.conn.CreateVolume(volume.Name, volume.Zone, volume.Size, volume.Snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("error creating volume %q: %v", volume.Name, err)
+	}
+	return created, nil
+}
+
+func (c *volumeClient) delete(name, zone string) error {
+	if err := c.conn.DeleteVolume(name, zone); err != nil {
+		return fmt.Errorf("error deleting volume %q: %v", name, err)
+	}
+	return nil
+}
+
+// replaceVolume replaces the volume by a copy named newName in newZone, of newSize GB,
+// through a snapshot of the volume. The copy is created before the volume is deleted
+// when both may exist at once, i.e. when the name or the zone changes, otherwise the
+// volume is deleted first. A failed step is rolled back, the snapshot is kept if the
+// volume could not be restored.
+func (c *volumeClient) replaceVolume(volume *hyperclient.VolumeResponse, newName, newZone string, newSize int) error {
+	if volume.Pod != "" {
+		return fmt.Errorf("volume %q is used by pod %s, delete the pod first", volume.Name, volume.Pod)
+	}
+	// a snapshot is taken of the volume of that name whatever its zone
+	volumes, err := c.list("")
+	if err != nil {
+		return err
+	}
+	for _, other := range volumes {
+		if other.Name == volume.Name && other.Zone != volume.Zone {
+			return fmt.Errorf("volume %q also exists in zone %s, the snapshot of the volume of zone %s can not be taken", volume.Name, other.Zone, volume.Zone)
+		}
+	}
+	ctx := context.Background()
+	snapshot, err := c.cli.Client.SnapshotCreate(ctx, types.SnapshotCreateRequest{
+		Name:   fmt.Sprintf("%s-%s", volume.Name, utilrand.String(5)),
+		Volume: volume.Name,
+	})
+	if err != nil {
+		return fmt.Errorf("error taking a snapshot of volume %q: %v", volume.Name, err)
+	}
+	fmt.Fprintf(c.out, "snapshot \"%s\" of volume \"%s\" created\n", snapshot.Name, volume.Name)
+
+	original := pi.VolumeCreateRequest{Name: volume.Name, Zone: volume.Zone, Size: volume.Size, Snapshot: snapshot.Name}
+	replacement := pi.VolumeCreateRequest{Name: newName, Zone: newZone, Size: newSize, Snapshot: snapshot.Name}
+	if newName != volume.Name || newZone != volume.Zone {
+		if _, err := c.create(replacement); err != nil {
+			c.removeSnapshot(snapshot.Name)
+			return err
+		}
+		fmt.Fprintf(c.out, "volume \"%s\" created in zone %s from snapshot \"%s\"\n", newName, newZone, snapshot.Name)
+		if err := c.delete(volume.Name, volume.Zone); err != nil {
+			fmt.Fprintf(c.out, "rolling back, deleting volume \"%s\" in zone %s\n", newName, newZone)
+			if rollbackErr := c.delete(newName, newZone); rollbackErr != nil {
+				fmt.Fprintf(c.out, "error rolling back: %v\n", rollbackErr)
+			}
+			c.removeSnapshot(snapshot.Name)
+			return err
+		}
+		fmt.Fprintf(c.out, "volume \"%s\" deleted in zone %s\n", volume.Name, volume.Zone)
+	} else {
+		if err := c.delete(volume.Name, volume.Zone); err != nil {
+			c.removeSnapshot(snapshot.Name)
+			return err
+		}
+		fmt.Fprintf(c.out, "volume \"%s\" deleted in zone %s\n", volume.Name, volume.Zone)
+		if _, err := c.create(replacement); err != nil {
+			fmt.Fprintf(c.out, "rolling back, restoring volume \"%s\" from snapshot \"%s\"\n", volume.Name, snapshot.Name)
+			if _, rollbackErr := c.create(original); rollbackErr != nil {
+				return fmt.Errorf("%v, and restoring volume %q failed: %v; the data is kept in snapshot %q", err, volume.Name, rollbackErr, snapshot.Name)
+			}
+			c.removeSnapshot(snapshot.Name)
+			return err
+		}
+		fmt.Fprintf(c.out, "volume \"%s\" created in zone %s from snapshot \"%s\"\n", newName, newZone, snapshot.Name)
+	}
+	c.removeSnapshot(snapshot.Name)
+	return nil
+}
+
+// removeSnapshot removes the intermediate snapshot, a failure is only reported
+func (c *volumeClient) removeSnapshot(name string) {
+	if err := c.cli.Client.SnapshotRemove(context.Background(), name); err != nil {
+		fmt.Fprintf(c.out, "error deleting snapshot \"%s\": %v\n", name, err)
+		return
+	}
+	fmt.Fprintf(c.out, "snapshot \"%s\" deleted\n", name)
+}
@@ -0,0 +1,458 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	hyperclient "github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
+	"github.com/hyperhq/client-go/util/jsonpath"
+	"github.com/hyperhq/pi/pkg/hyper"
+	"github.com/hyperhq/pi/pkg/pi/cmd/templates"
+	cmdutil "github.com/hyperhq/pi/pkg/pi/cmd/util"
+	"github.com/hyperhq/pi/pkg/pi/resource"
+	"github.com/hyperhq/pi/pkg/pi/util/i18n"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var (
+	waitLong = templates.LongDesc(i18n.T(`
+		Wait for a condition on one or many resources.
+
+		--for=delete waits until the resources are deleted. --for=condition=NAME waits until the
+		condition NAME of the status of the resources is True, or the value given with
+		--for=condition=NAME=VALUE. --for=jsonpath={.path}=VALUE waits until the field of the
+		resources has the value.
+
+		A volume has the condition Detached once it is not used by a pod anymore.
+
+		The command exits with an error if a condition is not met within --timeout.`))
+
+	waitExample = templates.Examples(i18n.T(`
+		# Wait for the pod "web" to be ready
+		pi wait --for=condition=Ready pod/web
+
+		# Wait for the job "report" to complete, for up to 10 minutes
+		pi wait job/report --for=condition=Complete --timeout=10m
+
+		# Wait for the pods labeled app=wordpress to be running
+		pi wait pods -l app=wordpress --for=jsonpath={.status.phase}=Running
+
+		# Wait for the pod "web" to be deleted
+		pi wait pod/web --for=delete
+
+		# Wait for the volume "mysql-data" to be detached from its pod
+		pi wait volume/mysql-data --for=condition=Detached`))
+)
+
+// objectDeletionWaitInterval is the interval to wait between checks for deletion.
+var objectDeletionWaitInterval = time.Second
+
+// objectConditionWaitInterval is the interval to wait between checks of a condition.
+var objectConditionWaitInterval = time.Second
+
+// volumeDetachedCondition is the condition of a volume not used by a pod
+const volumeDetachedCondition = "Detached"
+
+// WaitOptions declare the arguments accepted by the Wait command
+type WaitOptions struct {
+	resource.FilenameOptions
+
+	Selector string
+	All      bool
+	For      string
+	Timeout  time.Duration
+	Zone     string
+	Output   string
+
+	forDelete      bool
+	conditionName  string
+	conditionValue string
+	jsonPath       *jsonpath.JSONPath
+	jsonPathValue  string
+
+	f    cmdutil.Factory
+	Args []string
+	Out  io.Writer
+	Err  io.Writer
+}
+
+// NewCmdWait creates a command to wait for a condition on resources
+func NewCmdWait(f cmdutil.Factory, out, errOut io.Writer) *cobra.Command {
+	options := &WaitOptions{
+		Out: out,
+		Err: errOut,
+	}
+	cmd := &cobra.Command{
+		Use:     "wait (TYPE [NAME] | TYPE/NAME | -f FILENAME) --for=delete|condition=NAME[=VALUE]|jsonpath={.path}=VALUE",
+		Short:   i18n.T("Wait for a condition on one or many resources"),
+		Long:    waitLong,
+		Example: waitExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(f, cmd, args))
+			cmdutil.CheckErr(options.Run())
+		},
+	}
+	cmdutil.AddFilenameOptionFlags(cmd, &options.FilenameOptions, "identifying the resource to wait for.")
+	cmd.Flags().StringVarP(&options.Selector, "selector", "l", options.Selector, "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().BoolVar(&options.All, "all", options.All, "Wait for all resources of the specified types")
+	cmd.Flags().StringVar(&options.For, "for", options.For, "The condition to wait for: delete, condition=NAME[=VALUE] or jsonpath={.path}=VALUE")
+	cmd.Flags().DurationVar(&options.Timeout, "timeout", 30*time.Second, "The length of time to wait before giving up, zero means check once")
+	cmd.Flags().StringVar(&options.Zone, "zone", options.Zone, "The zone of the volumes to wait for")
+	cmdutil.AddOutputVarFlagsForMutation(cmd, &options.Output)
+	return cmd
+}
+
+// Complete parses --for and checks the resources to wait for are given
+func (o *WaitOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 && cmdutil.IsFilenameSliceEmpty(o.Filenames) {
+		return cmdutil.UsageErrorf(cmd, "Required resource not specified.")
+	}
+	if err := o.parseFor(); err != nil {
+		return cmdutil.UsageErrorf(cmd, err.Error())
+	}
+	if o.Timeout < 0 {
+		return cmdutil.UsageErrorf(cmd, "--timeout must not be negative")
+	}
+	o.f = f
+	o.Args = args
+	return nil
+}
+
+// parseFor parses the condition given with --for
+func (o *WaitOptions) parseFor() error {
+	switch {
+	case len(o.For) == 0:
+		return fmt.Errorf("--for must be specified")
+	case strings.ToLower(o.For) == "delete":
+		o.forDelete = true
+	case strings.HasPrefix(o.For, "condition="):
+		condition := strings.TrimPrefix(o.For, "condition=")
+		o.conditionName, o.conditionValue = condition, "True"
+		if i := strings.Index(condition, "="); i >= 0 {
+			o.conditionName, o.conditionValue = condition[:i], condition[i+1:]
+		}
+		if len(o.conditionName) == 0 {
+			return fmt.Errorf("--for=condition requires a condition name, e.g. --for=condition=Ready")
+		}
+	case strings.HasPrefix(o.For, "jsonpath="):
+		expression := strings.TrimPrefix(o.For, "jsonpath=")
+		// the path may hold '=' in a filter, the value follows the last '}'
+		end := strings.LastIndex(expression, "}")
+		if !strings.HasPrefix(expression, "{") || end < 0 || !strings.HasPrefix(expression[end+1:], "=") {
+			return fmt.Errorf("--for=jsonpath requires a path and a value, e.g. --for=jsonpath={.status.phase}=Running")
+		}
+		o.jsonPath = jsonpath.New("wait").AllowMissingKeys(true)
+		if err := o.jsonPath.Parse(expression[:end+1]); err != nil {
+			return fmt.Errorf("error parsing jsonpath %s: %v", expression[:end+1], err)
+		}
+		o.jsonPathValue = expression[end+2:]
+	default:
+		return fmt.Errorf("unrecognized condition %q, one of: delete, condition=NAME[=VALUE], jsonpath={.path}=VALUE", o.For)
+	}
+	return nil
+}
+
+// poll checks condition every interval until it is met, or the timeout is
+// reached. A zero timeout checks the condition once.
+func (o *WaitOptions) poll(interval time.Duration, condition wait.ConditionFunc) error {
+	if o.Timeout > 0 {
+		return wait.PollImmediate(interval, o.Timeout, condition)
+	}
+	done, err := condition()
+	if err == nil && !done {
+		err = wait.ErrWaitTimeout
+	}
+	return err
+}
+
+// Run waits for the condition on every resource in turn, each within the timeout.
+func (o *WaitOptions) Run() error {
+	if len(o.Args) > 0 && isVolumeArg(o.Args[0]) {
+		return o.waitForVolumes()
+	}
+
+	cmdNamespace, enforceNamespace, err := o.f.DefaultNamespace()
+	if err != nil {
+		return err
+	}
+	r := o.f.NewBuilder().
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(cmdNamespace).DefaultNamespace().
+		FilenameParam(enforceNamespace, &o.FilenameOptions).
+		LabelSelectorParam(o.Selector).
+		SelectAllParam(o.All).
+		ResourceTypeOrNameArgs(false, o.Args...).RequireObject(false).
+		Flatten().
+		Do()
+	if err := r.Err(); err != nil {
+		return err
+	}
+	mapper := r.Mapper().RESTMapper
+	shortOutput := o.Output == "name"
+
+	found := 0
+	err = r.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+		found++
+		if o.forDelete {
+			err = o.waitForDeletion(info)
+		} else {
+			err = o.poll(objectConditionWaitInterval, objectConditionMet(info, o.objectConditionMet))
+		}
+		if err == wait.ErrWaitTimeout {
+			return fmt.Errorf("timed out waiting for %s on %s/%s", o.For, info.Mapping.Resource, info.Name)
+		}
+		if err != nil {
+			return cmdutil.AddSourceToErr("waiting", info.Source, err)
+		}
+		operation := "condition met"
+		if o.forDelete {
+			operation = "deleted"
+		}
+		o.f.PrintSuccess(mapper, shortOutput, o.Out, info.Mapping.Resource, info.Name, false, operation)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if found == 0 {
+		return fmt.Errorf("no matching resources found")
+	}
+	return nil
+}
+
+// objectConditionMet checks the condition of --for on the object of a resource
+func (o *WaitOptions) objectConditionMet(obj map[string]interface{}) (bool, error) {
+	if o.jsonPath != nil {
+		return o.jsonPathMet(obj)
+	}
+	conditions, _ := unstructured.NestedSlice(obj, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := condition["type"].(string)
+		if !strings.EqualFold(name, o.conditionName) {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		return strings.EqualFold(status, o.conditionValue), nil
+	}
+	return false, nil
+}
+
+// jsonPathMet returns true if a field matched by the jsonpath has the expected value
+func (o *WaitOptions) jsonPathMet(obj interface{}) (bool, error) {
+	results, err := o.jsonPath.FindResults(obj)
+	if err != nil {
+		return false, err
+	}
+	for _, result := range results {
+		for _, value := range result {
+			if fmt.Sprint(value.Interface()) == o.jsonPathValue {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// waitForVolumes waits for the condition on the volumes of the args, given as
+// "volume NAME..." or "volume/NAME..."
+func (o *WaitOptions) waitForVolumes() error {
+	names, err := volumeNames(o.Args)
+	if err != nil {
+		return err
+	}
+	if o.conditionName != "" && !strings.EqualFold(o.conditionName, volumeDetachedCondition) {
+		return fmt.Errorf("unsupported condition %q for volumes, one of: %s", o.conditionName, volumeDetachedCondition)
+	}
+	cfg, err := o.f.ClientConfig()
+	if err != nil {
+		return err
+	}
+	conn, err := hyper.NewConn(cfg)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err := o.poll(objectConditionWaitInterval, func() (bool, error) {
+			volumes, err := conn.ListVolumes(o.Zone)
+			if err != nil {
+				return false, err
+			}
+			for _, volume := range volumes {
+				if volume.Name != name {
+					continue
+				}
+				switch {
+				case o.forDelete:
+					return false, nil
+				case o.jsonPath != nil:
+					return o.volumeJSONPathMet(volume)
+				}
+				detached := volume.Pod == ""
+				return detached == strings.EqualFold(o.conditionValue, "True"), nil
+			}
+			if o.forDelete {
+				return true, nil
+			}
+			return false, fmt.Errorf("volume %q not found", name)
+		})
+		if err == wait.ErrWaitTimeout {
+			return fmt.Errorf("timed out waiting for %s on volume/%s", o.For, name)
+		}
+		if err != nil {
+			return err
+		}
+		operation := "condition met"
+		if o.forDelete {
+			operation = "deleted"
+		}
+		printHyperSuccess(o.Out, o.Output == "name", resourceInfoOfVolume(name), false, operation)
+	}
+	return nil
+}
+
+// volumeJSONPathMet checks the jsonpath on the JSON representation of a volume
+func (o *WaitOptions) volumeJSONPathMet(volume hyperclient.VolumeResponse) (bool, error) {
+	data, err := json.Marshal(volume)
+	if err != nil {
+		return false, err
+	}
+	obj := map[string]interface{}{}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return false, err
+	}
+	return o.jsonPathMet(obj)
+}
+
+// isVolumeArg returns true if arg is the volume type or a volume/NAME
+func isVolumeArg(arg string) bool {
+	resourceType := strings.SplitN(arg, "/", 2)[0]
+	return resourceType == "volume" || resourceType == "volumes"
+}
+
+// volumeNames returns the names of the volumes of the args
+func volumeNames(args []string) ([]string, error) {
+	names := []string{}
+	if !strings.Contains(args[0], "/") {
+		names = append(names, args[1:]...)
+	} else {
+		for _, arg := range args {
+			if !isVolumeArg(arg) || !strings.Contains(arg, "/") {
+				return nil, fmt.Errorf("volumes can not be waited for with other resources: %s", arg)
+			}
+			names = append(names, strings.SplitN(arg, "/", 2)[1])
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("the names of the volumes to wait for are required")
+	}
+	return names, nil
+}
+
+// resourceInfoOfVolume returns an info to print the volume name with
+func resourceInfoOfVolume(name string) *resource.Info {
+	return &resource.Info{
+		Name:    name,
+		Mapping: &meta.RESTMapping{GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: resource.VolumeKind}},
+	}
+}
+
+// waitForObjectDeletion refreshes the object, waiting until it is deleted, a timeout is reached, or
+// an error is encountered. It checks once a second.
+func waitForObjectDeletion(info *resource.Info, timeout time.Duration) error {
+	copied := *info
+	info = &copied
+	// TODO: refactor Reaper so that we can pass the "wait" option into it, and then check for UID change.
+	return wait.PollImmediate(objectDeletionWaitInterval, timeout, func() (bool, error) {
+		switch err := info.Get(); {
+		case err == nil:
+			return false, nil
+		case errors.IsNotFound(err):
+			return true, nil
+		default:
+			return false, err
+		}
+	})
+}
+
+// waitForDeletion watches the object from the version read first until it is
+// deleted, within the timeout. A zero timeout checks the object once.
+func (o *WaitOptions) waitForDeletion(info *resource.Info) error {
+	copied := *info
+	info = &copied
+	switch err := info.Get(); {
+	case errors.IsNotFound(err):
+		return nil
+	case err != nil:
+		return err
+	case o.Timeout <= 0:
+		return wait.ErrWaitTimeout
+	}
+	w, err := info.Watch(info.ResourceVersion)
+	if err != nil {
+		return err
+	}
+	defer w.Stop()
+	_, err = watch.Until(o.Timeout, w, func(ev watch.Event) (bool, error) {
+		return ev.Type == watch.Deleted, nil
+	})
+	return err
+}
+
+// objectConditionMet returns a condition refreshing the object, met once met returns true for
+// it. An object not found yet does not meet the condition.
+func objectConditionMet(info *resource.Info, met func(obj map[string]interface{}) (bool, error)) wait.ConditionFunc {
+	copied := *info
+	info = &copied
+	return func() (bool, error) {
+		switch err := info.Get(); {
+		case errors.IsNotFound(err):
+			return false, nil
+		case err != nil:
+			return false, err
+		}
+		if obj, ok := info.Object.(*unstructured.Unstructured); ok {
+			return met(obj.Object)
+		}
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(info.Object)
+		if err != nil {
+			return false, err
+		}
+		return met(obj)
+	}
+}
@@ -0,0 +1,281 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyperhq/client-go/util/homedir"
+
+	"github.com/docker/docker/pkg/term"
+)
+
+const (
+	// PassphraseEnv is the environment variable the passphrase of the file store is read
+	// from. The passphrase is asked on the terminal when it is not set.
+	PassphraseEnv = "PI_CREDENTIAL_PASSPHRASE"
+
+	fileStoreVersion = 1
+	// iterations of PBKDF2 to derive the encryption key from the passphrase
+	keyIterations = 100000
+	keyLength     = 32
+	saltLength    = 16
+)
+
+// DefaultFilePath returns the path of the encrypted credentials file, ~/.pi/credentials
+func DefaultFilePath() string {
+	return filepath.Join(homedir.HomeDir(), ".pi", "credentials")
+}
+
+// encryptedFile is the content of the credentials file. Data is the JSON encoded
+// map of user names to credentials, sealed with AES-256-GCM.
+type encryptedFile struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// EncryptedFileStore keeps the credentials in a file encrypted with a passphrase
+type EncryptedFileStore struct {
+	path       string
+	passphrase string
+
+	// Passphrase returns the passphrase of the file, confirm is set when the file
+	// is created and the passphrase should be typed twice.
+	Passphrase func(path string, confirm bool) (string, error)
+}
+
+// NewFileStore returns a store keeping the credentials in the encrypted file path
+func NewFileStore(path string) *EncryptedFileStore {
+	return &EncryptedFileStore{path: path, Passphrase: readPassphrase}
+}
+
+func (s *EncryptedFileStore) Name() string {
+	return FileStore
+}
+
+func (s *EncryptedFileStore) Get(user string) (Credential, error) {
+	credentials, err := s.load(false)
+	if err != nil {
+		return Credential{}, err
+	}
+	credential, found := credentials[user]
+	if !found {
+		return Credential{}, fmt.Errorf("no credentials found for user %q in %s", user, s.path)
+	}
+	return credential, nil
+}
+
+func (s *EncryptedFileStore) Store(user string, credential Credential) error {
+	credentials, err := s.load(true)
+	if err != nil {
+		return err
+	}
+	credentials[user] = credential
+	return s.save(credentials)
+}
+
+func (s *EncryptedFileStore) Erase(user string) error {
+	if _, err := os.Stat(s.path); os.IsNotExist(err) {
+		return nil
+	}
+	credentials, err := s.load(false)
+	if err != nil {
+		return err
+	}
+	if _, found := credentials[user]; !found {
+		return nil
+	}
+	delete(credentials, user)
+	return s.save(credentials)
+}
+
+// load decrypts the credentials of the file. A missing file has no credentials
+// when create is set.
+func (s *EncryptedFileStore) load(create bool) (map[string]Credential, error) {
+	buf, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) && create {
+		if s.passphrase == "" {
+			if s.passphrase, err = s.Passphrase(s.path, true); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]Credential{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var file encryptedFile
+	if err := json.Unmarshal(buf, &file); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", s.path, err)
+	}
+	if file.Version != fileStoreVersion {
+		return nil, fmt.Errorf("error reading %s: unsupported version %d", s.path, file.Version)
+	}
+	if s.passphrase == "" {
+		if s.passphrase, err = s.Passphrase(s.path, false); err != nil {
+			return nil, err
+		}
+	}
+	gcm, err := newGCM(s.passphrase, file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	data, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		s.passphrase = ""
+		return nil, fmt.Errorf("error decrypting %s: wrong passphrase or corrupted file", s.path)
+	}
+
+	credentials := map[string]Credential{}
+	if err := json.Unmarshal(data, &credentials); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", s.path, err)
+	}
+	return credentials, nil
+}
+
+// save encrypts the credentials into the file, with a new salt and nonce
+func (s *EncryptedFileStore) save(credentials map[string]Credential) error {
+	data, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	file := encryptedFile{
+		Version: fileStoreVersion,
+		Salt:    make([]byte, saltLength),
+	}
+	if _, err := io.ReadFull(rand.Reader, file.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(s.passphrase, file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, data, nil)
+
+	buf, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, buf, 0600)
+}
+
+// newGCM returns the AES-256-GCM cipher keyed with the passphrase
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2([]byte(passphrase), salt, keyIterations, keyLength))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// pbkdf2 derives a key of keyLen bytes from the password with PBKDF2-HMAC-SHA256 (RFC 8018)
+func pbkdf2(password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(sha256.New, password)
+	var key []byte
+	for block := uint32(1); len(key) < keyLen; block++ {
+		prf.Reset()
+		prf.Write(salt)
+		var counter [4]byte
+		binary.BigEndian.PutUint32(counter[:], block)
+		prf.Write(counter[:])
+		u := prf.Sum(nil)
+		t := append([]byte{}, u...)
+		for i := 1; i < iterations; i++ {
+			prf.Reset()
+			prf.Write(u)
+			u = prf.Sum(u[:0])
+			for j := range t {
+				t[j] ^= u[j]
+			}
+		}
+		key = append(key, t...)
+	}
+	return key[:keyLen]
+}
+
+// readPassphrase reads the passphrase from PI_CREDENTIAL_PASSPHRASE, or asks it on the terminal
+func readPassphrase(path string, confirm bool) (string, error) {
+	if passphrase := os.Getenv(PassphraseEnv); passphrase != "" {
+		return passphrase, nil
+	}
+	fd, isTerminal := term.GetFdInfo(os.Stdin)
+	if !isTerminal {
+		return "", fmt.Errorf("the passphrase of %s is required, set %s or run pi in a terminal", path, PassphraseEnv)
+	}
+
+	passphrase, err := promptPassphrase(fd, fmt.Sprintf("Passphrase for %s: ", path))
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("the passphrase must not be empty")
+	}
+	if confirm {
+		again, err := promptPassphrase(fd, "Confirm passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if again != passphrase {
+			return "", errors.New("the passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
+
+// promptPassphrase reads a line from the terminal fd without echoing it
+func promptPassphrase(fd uintptr, prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	state, err := term.SaveState(fd)
+	if err != nil {
+		return "", err
+	}
+	if err := term.DisableEcho(fd, state); err != nil {
+		return "", err
+	}
+	defer term.RestoreTerminal(fd, state)
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const (
+	// HelperPrefix is the prefix of the executable of a credential helper,
+	// the helper named osxkeychain is run as pi-credential-osxkeychain.
+	HelperPrefix = "pi-credential-"
+
+	helperProtocol = "pi"
+)
+
+// HelperStore delegates the credentials to an external credential helper. The
+// helper speaks the git credential helper protocol: it is run with one of the
+// actions get, store or erase, and exchanges key=value lines on stdin and stdout.
+// The user name is sent as host, the access key and the secret key as username
+// and password, so the credential helpers of git can be used as well, e.g.
+//
+//	ln -s $(which git-credential-osxkeychain) /usr/local/bin/pi-credential-osxkeychain
+type HelperStore struct {
+	name string
+
+	// Run runs the helper with action and the attributes as input, and returns its output
+	Run func(action string, input []byte) ([]byte, error)
+}
+
+// NewHelperStore returns a store backed by the credential helper named name. A
+// name with a path separator is the path of the helper executable.
+func NewHelperStore(name string) *HelperStore {
+	s := &HelperStore{name: name}
+	s.Run = s.exec
+	return s
+}
+
+func (s *HelperStore) Name() string {
+	return s.name
+}
+
+func (s *HelperStore) Get(user string) (Credential, error) {
+	out, err := s.Run("get", encodeAttributes(s.attributes(user, nil)))
+	if err != nil {
+		return Credential{}, err
+	}
+	attributes, err := decodeAttributes(bytes.NewReader(out))
+	if err != nil {
+		return Credential{}, err
+	}
+	credential := Credential{AccessKey: attributes["username"], SecretKey: attributes["password"]}
+	if credential.AccessKey == "" || credential.SecretKey == "" {
+		return Credential{}, fmt.Errorf("no credentials found for user %q in credential helper %s", user, s.name)
+	}
+	return credential, nil
+}
+
+func (s *HelperStore) Store(user string, credential Credential) error {
+	_, err := s.Run("store", encodeAttributes(s.attributes(user, &credential)))
+	return err
+}
+
+func (s *HelperStore) Erase(user string) error {
+	_, err := s.Run("erase", encodeAttributes(s.attributes(user, nil)))
+	return err
+}
+
+// attributes returns the attributes identifying the credential of user
+func (s *HelperStore) attributes(user string, credential *Credential) [][2]string {
+	attributes := [][2]string{
+		{"protocol", helperProtocol},
+		{"host", user},
+	}
+	if credential != nil {
+		attributes = append(attributes,
+			[2]string{"username", credential.AccessKey},
+			[2]string{"password", credential.SecretKey})
+	}
+	return attributes
+}
+
+// exec runs the helper executable
+func (s *HelperStore) exec(action string, input []byte) ([]byte, error) {
+	path := s.name
+	if !strings.ContainsRune(path, os.PathSeparator) {
+		path = HelperPrefix + s.name
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path, action)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("credential helper %s %s: %v: %s", path, action, err, msg)
+		}
+		return nil, fmt.Errorf("credential helper %s %s: %v", path, action, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// encodeAttributes writes the attributes as key=value lines, ended by a blank line
+func encodeAttributes(attributes [][2]string) []byte {
+	var buf bytes.Buffer
+	for _, attribute := range attributes {
+		fmt.Fprintf(&buf, "%s=%s\n", attribute[0], attribute[1])
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// decodeAttributes reads key=value lines until a blank line or the end of r
+func decodeAttributes(r io.Reader) (map[string]string, error) {
+	attributes := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid credential helper output %q", line)
+		}
+		attributes[parts[0]] = parts[1]
+	}
+	return attributes, scanner.Err()
+}
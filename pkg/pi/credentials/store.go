@@ -0,0 +1,172 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package credentials stores the access and secret keys of the users of the
+// pi config, either in the config itself, in a passphrase-encrypted file or
+// in an external credential helper.
+package credentials
+
+import (
+	"fmt"
+	"sync"
+
+	clientcmdapi "github.com/hyperhq/client-go/tools/clientcmd/api"
+)
+
+const (
+	// PlaintextStore keeps the keys in the pi config, as access-key and secret-key
+	PlaintextStore = "plaintext"
+	// FileStore keeps the keys in a file encrypted with a passphrase
+	FileStore = "file"
+)
+
+// Credential is the pair of keys a user signs the requests with
+type Credential struct {
+	AccessKey string `json:"access-key"`
+	SecretKey string `json:"secret-key"`
+}
+
+// Store reads, writes and removes the credential of the users of the pi config
+type Store interface {
+	// Name is the name the pi config references the store with
+	Name() string
+	Get(user string) (Credential, error)
+	Store(user string, credential Credential) error
+	Erase(user string) error
+}
+
+// NewStore returns the store named name. The plaintext store keeps the keys in
+// the auth infos of config, every name other than plaintext and file is the name
+// of a credential helper.
+func NewStore(name string, config *clientcmdapi.Config) (Store, error) {
+	switch name {
+	case "", PlaintextStore:
+		if config == nil {
+			return nil, fmt.Errorf("the %s credential store requires a pi config", PlaintextStore)
+		}
+		return &plaintextStore{config: config}, nil
+	case FileStore:
+		return NewFileStore(DefaultFilePath()), nil
+	default:
+		return NewHelperStore(name), nil
+	}
+}
+
+// AuthProviderName is the name of the auth provider of the auth infos whose keys are
+// kept in a credential store, the store is named by the store key of its config:
+//
+//	auth-provider:
+//	  name: pi-credential-store
+//	  config:
+//	    store: file
+//
+// pi reads the keys from the store instead of running an auth provider plugin.
+const AuthProviderName = "pi-credential-store"
+
+// StoreReference returns the value StoreName returns for an auth info whose keys are
+// kept in the store named name. The plaintext store is referenced by no value.
+func StoreReference(name string) string {
+	if name == PlaintextStore {
+		return ""
+	}
+	return name
+}
+
+// StoreName returns the name of the credential store the keys of authInfo are kept
+// in, or "" if they are kept in the auth info
+func StoreName(authInfo *clientcmdapi.AuthInfo) string {
+	if authInfo.AuthProvider == nil || authInfo.AuthProvider.Name != AuthProviderName {
+		return ""
+	}
+	return authInfo.AuthProvider.Config["store"]
+}
+
+// SetStoreName references the credential store named name in authInfo
+func SetStoreName(authInfo *clientcmdapi.AuthInfo, name string) {
+	if reference := StoreReference(name); reference != "" {
+		authInfo.AuthProvider = &clientcmdapi.AuthProviderConfig{Name: AuthProviderName, Config: map[string]string{"store": reference}}
+	} else if authInfo.AuthProvider != nil && authInfo.AuthProvider.Name == AuthProviderName {
+		authInfo.AuthProvider = nil
+	}
+}
+
+var (
+	loadedLock sync.Mutex
+	loaded     = map[string]Credential{}
+)
+
+// LoadCredential reads the keys of the user authInfoName from the store its auth
+// info references. The keys are read once per process, so the passphrase of the
+// file store is asked at most once.
+func LoadCredential(authInfoName string, authInfo clientcmdapi.AuthInfo) (string, string, error) {
+	storeName := StoreName(&authInfo)
+	if storeName == "" || storeName == PlaintextStore {
+		return authInfo.AccessKey, authInfo.SecretKey, nil
+	}
+
+	loadedLock.Lock()
+	defer loadedLock.Unlock()
+	key := storeName + "/" + authInfoName
+	if credential, found := loaded[key]; found {
+		return credential.AccessKey, credential.SecretKey, nil
+	}
+	store, err := NewStore(storeName, nil)
+	if err != nil {
+		return "", "", err
+	}
+	credential, err := store.Get(authInfoName)
+	if err != nil {
+		return "", "", fmt.Errorf("error reading the credentials of user %q from the %s credential store: %v", authInfoName, storeName, err)
+	}
+	loaded[key] = credential
+	return credential.AccessKey, credential.SecretKey, nil
+}
+
+// plaintextStore keeps the keys in the auth infos of the pi config
+type plaintextStore struct {
+	config *clientcmdapi.Config
+}
+
+func (s *plaintextStore) Name() string {
+	return PlaintextStore
+}
+
+func (s *plaintextStore) Get(user string) (Credential, error) {
+	authInfo, found := s.config.AuthInfos[user]
+	if !found || (authInfo.AccessKey == "" && authInfo.SecretKey == "") {
+		return Credential{}, fmt.Errorf("no credentials found for user %q", user)
+	}
+	return Credential{AccessKey: authInfo.AccessKey, SecretKey: authInfo.SecretKey}, nil
+}
+
+func (s *plaintextStore) Store(user string, credential Credential) error {
+	authInfo, found := s.config.AuthInfos[user]
+	if !found {
+		authInfo = clientcmdapi.NewAuthInfo()
+		s.config.AuthInfos[user] = authInfo
+	}
+	authInfo.AccessKey = credential.AccessKey
+	authInfo.SecretKey = credential.SecretKey
+	return nil
+}
+
+func (s *plaintextStore) Erase(user string) error {
+	if authInfo, found := s.config.AuthInfos[user]; found {
+		authInfo.AccessKey = ""
+		authInfo.SecretKey = ""
+	}
+	return nil
+}
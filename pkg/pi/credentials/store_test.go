@@ -0,0 +1,134 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package credentials
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pi-credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials")
+
+	credential := Credential{AccessKey: "ACCESS", SecretKey: "SECRET"}
+	store := NewFileStore(path)
+	store.Passphrase = func(string, bool) (string, error) { return "passphrase", nil }
+	if err := store.Store("user1", credential); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf, []byte("SECRET")) {
+		t.Errorf("the secret key is stored in plaintext: %s", buf)
+	}
+
+	store = NewFileStore(path)
+	store.Passphrase = func(string, bool) (string, error) { return "passphrase", nil }
+	if saw, err := store.Get("user1"); err != nil || saw != credential {
+		t.Errorf("expected %v, saw %v (%v)", credential, saw, err)
+	}
+	if err := store.Erase("user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Get("user1"); err == nil {
+		t.Errorf("expected an error for an erased user")
+	}
+
+	store = NewFileStore(path)
+	store.Passphrase = func(string, bool) (string, error) { return "wrong", nil }
+	if _, err := store.Get("user1"); err == nil {
+		t.Errorf("expected an error for a wrong passphrase")
+	}
+}
+
+// TestPBKDF2 checks the PBKDF2-HMAC-SHA256 vectors of RFC 7914 section 11
+func TestPBKDF2(t *testing.T) {
+	tests := []struct {
+		password   string
+		salt       string
+		iterations int
+		keyLen     int
+		expected   string
+	}{
+		{
+			password:   "passwd",
+			salt:       "salt",
+			iterations: 1,
+			keyLen:     64,
+			expected:   "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783",
+		},
+		{
+			password:   "Password",
+			salt:       "NaCl",
+			iterations: 80000,
+			keyLen:     64,
+			expected:   "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d",
+		},
+		{
+			// a key length which is not a multiple of the hash size
+			password:   "Password",
+			salt:       "NaCl",
+			iterations: 80000,
+			keyLen:     40,
+			expected:   "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56a1d425a122583354",
+		},
+	}
+	for _, test := range tests {
+		key := hex.EncodeToString(pbkdf2([]byte(test.password), []byte(test.salt), test.iterations, test.keyLen))
+		if key != test.expected {
+			t.Errorf("pbkdf2(%q, %q, %d, %d): expected %s, saw %s", test.password, test.salt, test.iterations, test.keyLen, test.expected, key)
+		}
+	}
+}
+
+func TestHelperStore(t *testing.T) {
+	var actions, inputs []string
+	store := NewHelperStore("test")
+	store.Run = func(action string, input []byte) ([]byte, error) {
+		actions = append(actions, action)
+		inputs = append(inputs, string(input))
+		return []byte("protocol=pi\nhost=user1\nusername=ACCESS\npassword=SECRET\n"), nil
+	}
+
+	credential := Credential{AccessKey: "ACCESS", SecretKey: "SECRET"}
+	if err := store.Store("user1", credential); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if saw, err := store.Get("user1"); err != nil || saw != credential {
+		t.Errorf("expected %v, saw %v (%v)", credential, saw, err)
+	}
+
+	expected := []string{
+		"protocol=pi\nhost=user1\nusername=ACCESS\npassword=SECRET\n\n",
+		"protocol=pi\nhost=user1\n\n",
+	}
+	if len(inputs) != 2 || inputs[0] != expected[0] || inputs[1] != expected[1] || actions[0] != "store" || actions[1] != "get" {
+		t.Errorf("expected store %q and get %q, saw %v %q", expected[0], expected[1], actions, inputs)
+	}
+}
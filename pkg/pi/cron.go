@@ -0,0 +1,218 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/hyper-api/types/container"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Cron is a hyper cron, as generated by CronGeneratorV1
+type Cron struct {
+	types.Cron
+}
+
+func (c *Cron) GetObjectKind() schema.ObjectKind {
+	return schema.EmptyObjectKind
+}
+
+func (c *Cron) DeepCopyObject() runtime.Object {
+	out := *c
+	return &out
+}
+
+// CronGeneratorV1 generates a hyper cron, which runs a job pod on a schedule
+type CronGeneratorV1 struct{}
+
+func (CronGeneratorV1) ParamNames() []GeneratorParam {
+	return []GeneratorParam{
+		{"labels", false},
+		{"default-name", false},
+		{"name", true},
+		{"schedule", true},
+		{"image", true},
+		{"command", false},
+		{"args", false},
+		{"env", false},
+		{"size", false},
+		{"image-pull-secrets", false},
+		{"security-group", false},
+		{"volume", false},
+	}
+}
+
+func (CronGeneratorV1) Generate(genericParams map[string]interface{}) (runtime.Object, error) {
+	args, err := getArgs(genericParams)
+	if err != nil {
+		return nil, err
+	}
+
+	envs, err := getEnvs(genericParams)
+	if err != nil {
+		return nil, err
+	}
+
+	volumes, volumeMounts, err := getVolumes(genericParams)
+	if err != nil {
+		return nil, err
+	}
+
+	params, err := getParams(genericParams)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := getName(params)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := params["schedule"]
+	if err := validateSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	labels, err := getLabels(params, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(params["size"]) > 0 {
+		labels["sh_hyper_instancetype"] = params["size"]
+	}
+	labels = updateSecurityGroups(params, labels)
+	// the cron API has no field for the registry credentials of the image
+	if len(params["image-pull-secrets"]) > 0 {
+		return nil, fmt.Errorf("--image-pull-secrets is not supported for cronjobs")
+	}
+
+	config := &container.Config{
+		Image:  params["image"],
+		Labels: labels,
+	}
+	for _, env := range envs {
+		config.Env = append(config.Env, env.Name+"="+env.Value)
+	}
+	if len(args) > 0 {
+		command, err := GetBool(params, "command", false)
+		if err != nil {
+			return nil, err
+		}
+		if command {
+			config.Entrypoint = args
+		} else {
+			config.Cmd = args
+		}
+	}
+
+	hostConfig := &container.HostConfig{}
+	for i, volume := range volumes {
+		hostConfig.Binds = append(hostConfig.Binds, volume.FlexVolume.Options["volumeID"]+":"+volumeMounts[i].MountPath)
+	}
+
+	return &Cron{
+		Cron: types.Cron{
+			Name:          name,
+			Schedule:      schedule,
+			ContainerName: name,
+			Config:        config,
+			HostConfig:    hostConfig,
+		},
+	}, nil
+}
+
+// cronField is a field of a schedule in the Cron format, with the range of its
+// values and the names which may be used instead, e.g. JAN for 1 in the months
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day-of-month", 1, 31, nil},
+	{"month", 1, 12, []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{"day-of-week", 0, 6, []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+// validateSchedule checks that each field of a schedule in the Cron format is a
+// comma separated list of *, values or ranges of values of the field, each one
+// optionally followed by a /step, e.g. "*/15 9-17 * JAN,JUL 1-5"
+func validateSchedule(schedule string) error {
+	fields := strings.Fields(schedule)
+	if len(fields) != len(cronFields) {
+		return fmt.Errorf("invalid schedule %q, expected 5 fields: minute hour day-of-month month day-of-week", schedule)
+	}
+	for i, field := range fields {
+		if err := cronFields[i].validate(field); err != nil {
+			return fmt.Errorf("invalid schedule %q: %v", schedule, err)
+		}
+	}
+	return nil
+}
+
+func (f cronField) validate(field string) error {
+	for _, item := range strings.Split(field, ",") {
+		values := item
+		if n := strings.Index(item, "/"); n >= 0 {
+			values = item[:n]
+			if step, err := strconv.Atoi(item[n+1:]); err != nil || step <= 0 {
+				return fmt.Errorf("invalid step %q of the %s, expected a positive number", item[n+1:], f.name)
+			}
+		}
+		if values == "*" {
+			continue
+		}
+		bounds := strings.SplitN(values, "-", 2)
+		low, err := f.value(bounds[0])
+		if err != nil {
+			return err
+		}
+		if len(bounds) == 2 {
+			high, err := f.value(bounds[1])
+			if err != nil {
+				return err
+			}
+			if low > high {
+				return fmt.Errorf("invalid range %q of the %s, %s is after %s", values, f.name, bounds[0], bounds[1])
+			}
+		}
+	}
+	return nil
+}
+
+// value returns the value of a number or a name of the field
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	value, err := strconv.Atoi(s)
+	if err != nil || value < f.min || value > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected a value from %d to %d", f.name, s, f.min, f.max)
+	}
+	return value, nil
+}
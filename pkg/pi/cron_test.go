@@ -0,0 +1,137 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pi
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperhq/hyper-api/types"
+	"github.com/hyperhq/hyper-api/types/container"
+)
+
+func TestCronGenerate(t *testing.T) {
+	tests := map[string]struct {
+		params    map[string]interface{}
+		expected  *Cron
+		expectErr bool
+	}{
+		"test-valid-use": {
+			params: map[string]interface{}{
+				"name":     "report",
+				"schedule": "*/5 * * * *",
+				"image":    "report",
+			},
+			expected: &Cron{
+				Cron: types.Cron{
+					Name:          "report",
+					Schedule:      "*/5 * * * *",
+					ContainerName: "report",
+					Config: &container.Config{
+						Image:  "report",
+						Labels: map[string]string{"run": "report"},
+					},
+					HostConfig: &container.HostConfig{},
+				},
+			},
+		},
+		"test-job-flags": {
+			params: map[string]interface{}{
+				"name":           "report",
+				"schedule":       "0 1 * * *",
+				"image":          "report",
+				"labels":         "app=report",
+				"size":           "m1",
+				"security-group": "web",
+				"command":        "true",
+				"args":           []string{"/report.sh", "--all"},
+				"env":            []string{"MODE=nightly"},
+				"volume":         []string{"reports:/data"},
+			},
+			expected: &Cron{
+				Cron: types.Cron{
+					Name:          "report",
+					Schedule:      "0 1 * * *",
+					ContainerName: "report",
+					Config: &container.Config{
+						Image:      "report",
+						Env:        []string{"MODE=nightly"},
+						Entrypoint: []string{"/report.sh", "--all"},
+						Labels: map[string]string{
+							"app":                   "report",
+							"sh_hyper_instancetype": "m1",
+							"sh_hyper_sgroup_web":   "yes",
+						},
+					},
+					HostConfig: &container.HostConfig{
+						Binds: []string{"reports:/data"},
+					},
+				},
+			},
+		},
+		"test-image-pull-secrets": {
+			params: map[string]interface{}{
+				"name":               "report",
+				"schedule":           "0 1 * * *",
+				"image":              "registry.example.com/report",
+				"image-pull-secrets": "registry",
+			},
+			expectErr: true,
+		},
+		"test-invalid-schedule": {
+			params: map[string]interface{}{
+				"name":     "report",
+				"schedule": "5m",
+				"image":    "report",
+			},
+			expectErr: true,
+		},
+	}
+
+	generator := CronGeneratorV1{}
+	for name, test := range tests {
+		obj, err := generator.Generate(test.params)
+		if !test.expectErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if test.expectErr {
+			if err == nil {
+				t.Errorf("%s: expected error", name)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(obj.(*Cron), test.expected) {
+			t.Errorf("%s:\nexpected:\n%#v\nsaw:\n%#v", name, test.expected, obj.(*Cron))
+		}
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	valid := []string{"*/15 9-17 * * 1-5", "0 0 1,15 * *", "0 12 * JAN,jul SUN", "5-55/10 * 31 12 0-6"}
+	for _, schedule := range valid {
+		if err := validateSchedule(schedule); err != nil {
+			t.Errorf("%s: unexpected error: %v", schedule, err)
+		}
+	}
+	invalid := []string{"61 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 7", "*/0 * * * *", "10-5 * * * *", "* * * FOO *", "a * * * *", "* * * *"}
+	for _, schedule := range invalid {
+		if err := validateSchedule(schedule); err == nil {
+			t.Errorf("%s: expected error", schedule)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"strconv"
 	"text/tabwriter"
 
 	"github.com/hyperhq/client-go/kubernetes"
@@ -46,6 +47,13 @@ import (
 
 const (
 	ChangeCauseAnnotation = "kubernetes.io/change-cause"
+
+	// RevisionAnnotation is the revision of the spec of a pod, incremented by each
+	// 'pi rollout' replacing the pod. A pod without it is at revision 1.
+	RevisionAnnotation = "sh_hyper_revision"
+	// PreviousTemplateAnnotation is the v1 PodTemplateSpec, in JSON, of the pod
+	// replaced by 'pi rollout', with its revision and change-cause annotations
+	PreviousTemplateAnnotation = "sh_hyper_previous_template"
 )
 
 // HistoryViewer provides an interface for resources have historical information.
@@ -70,8 +78,11 @@ func (v *HistoryVisitor) VisitDaemonSet(kind kapps.GroupKindElement) {
 	v.result = &DaemonSetHistoryViewer{v.clientset}
 }
 
+func (v *HistoryVisitor) VisitPod(kind kapps.GroupKindElement) {
+	v.result = &PodHistoryViewer{v.clientset}
+}
+
 func (v *HistoryVisitor) VisitJob(kind kapps.GroupKindElement)                   {}
-func (v *HistoryVisitor) VisitPod(kind kapps.GroupKindElement)                   {}
 func (v *HistoryVisitor) VisitReplicaSet(kind kapps.GroupKindElement)            {}
 func (v *HistoryVisitor) VisitReplicationController(kind kapps.GroupKindElement) {}
 func (v *HistoryVisitor) VisitCronJob(kind kapps.GroupKindElement)               {}
@@ -179,6 +190,92 @@ func printTemplate(template *v1.PodTemplateSpec) (string, error) {
 	return buf.String(), nil
 }
 
+type PodHistoryViewer struct {
+	c kubernetes.Interface
+}
+
+// ViewHistory returns the revision of a pod, and the revision it replaced if any
+func (h *PodHistoryViewer) ViewHistory(namespace, name string, revision int64) (string, error) {
+	pod, err := h.c.CoreV1().Pods(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve pod %s: %v", name, err)
+	}
+	current, err := PodRevision(pod.Annotations)
+	if err != nil {
+		return "", err
+	}
+	historyInfo := map[int64]*v1.PodTemplateSpec{
+		current: {
+			ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{ChangeCauseAnnotation: getChangeCause(pod)}},
+			Spec:       pod.Spec,
+		},
+	}
+	previous, err := PreviousPodTemplate(pod.Annotations)
+	if err != nil {
+		return "", err
+	}
+	if previous != nil {
+		r, err := PodRevision(previous.Annotations)
+		if err != nil {
+			return "", err
+		}
+		historyInfo[r] = previous
+	}
+
+	if revision > 0 {
+		template, ok := historyInfo[revision]
+		if !ok {
+			return "", fmt.Errorf("unable to find the specified revision")
+		}
+		return printTemplate(template)
+	}
+
+	revisions := make([]int64, 0, len(historyInfo))
+	for r := range historyInfo {
+		revisions = append(revisions, r)
+	}
+	sliceutil.SortInts64(revisions)
+
+	return tabbedString(func(out io.Writer) error {
+		fmt.Fprintf(out, "REVISION\tCHANGE-CAUSE\n")
+		for _, r := range revisions {
+			changeCause := historyInfo[r].Annotations[ChangeCauseAnnotation]
+			if len(changeCause) == 0 {
+				changeCause = "<none>"
+			}
+			fmt.Fprintf(out, "%d\t%s\n", r, changeCause)
+		}
+		return nil
+	})
+}
+
+// PodRevision returns the revision in the given pod annotations
+func PodRevision(annotations map[string]string) (int64, error) {
+	value, found := annotations[RevisionAnnotation]
+	if !found {
+		return 1, nil
+	}
+	revision, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || revision < 1 {
+		return 0, fmt.Errorf("invalid %s annotation %q", RevisionAnnotation, value)
+	}
+	return revision, nil
+}
+
+// PreviousPodTemplate returns the template recorded in the given pod annotations
+// by the rollout which created the pod, nil if there is none
+func PreviousPodTemplate(annotations map[string]string) (*v1.PodTemplateSpec, error) {
+	value, found := annotations[PreviousTemplateAnnotation]
+	if !found {
+		return nil, nil
+	}
+	template := &v1.PodTemplateSpec{}
+	if err := json.Unmarshal([]byte(value), template); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %v", PreviousTemplateAnnotation, err)
+	}
+	return template, nil
+}
+
 type DaemonSetHistoryViewer struct {
 	c kubernetes.Interface
 }
@@ -0,0 +1,154 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricsutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	hyperclient "github.com/hyperhq/hyper-api/client"
+	"github.com/hyperhq/hyper-api/types"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	api "k8s.io/kubernetes/pkg/apis/core"
+	metricsapi "k8s.io/metrics/pkg/apis/metrics/v1alpha1"
+)
+
+// HyperStatsClient computes the metrics of pods from the stats of their
+// containers, as reported by the hyper api.
+type HyperStatsClient struct {
+	Client hyperclient.APIClient
+	// ErrOut receives the warnings about the containers whose stats can not be read
+	ErrOut io.Writer
+}
+
+func NewHyperStatsClient(client hyperclient.APIClient, errOut io.Writer) *HyperStatsClient {
+	return &HyperStatsClient{Client: client, ErrOut: errOut}
+}
+
+// GetPodMetrics returns the metrics of the running containers of pods, sorted by
+// container name. The stats of all the containers are read concurrently, a container
+// whose stats can not be read, e.g. as it just stopped, is skipped with a warning.
+func (cli *HyperStatsClient) GetPodMetrics(pods []api.Pod) ([]metricsapi.PodMetrics, error) {
+	metrics := make([]metricsapi.PodMetrics, len(pods))
+	var wg sync.WaitGroup
+	var lock sync.Mutex
+	for i, pod := range pods {
+		metrics[i] = metricsapi.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+			Timestamp:  metav1.Now(),
+			Containers: []metricsapi.ContainerMetrics{},
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			id := status.ContainerID
+			if n := strings.Index(id, "://"); n >= 0 {
+				id = id[n+len("://"):]
+			}
+			if len(id) == 0 || status.State.Running == nil {
+				continue
+			}
+			wg.Add(1)
+			go func(podMetrics *metricsapi.PodMetrics, name, id string) {
+				defer wg.Done()
+				usage, err := cli.getContainerUsage(id)
+				lock.Lock()
+				defer lock.Unlock()
+				if err != nil {
+					fmt.Fprintf(cli.ErrOut, "warning: could not read the stats of container %s of pod %s: %v\n", name, podMetrics.Name, err)
+					return
+				}
+				podMetrics.Containers = append(podMetrics.Containers, metricsapi.ContainerMetrics{Name: name, Usage: usage})
+			}(&metrics[i], status.Name, id)
+		}
+	}
+	wg.Wait()
+
+	for i := range metrics {
+		containers := metrics[i].Containers
+		sort.Slice(containers, func(a, b int) bool { return containers[a].Name < containers[b].Name })
+	}
+	return metrics, nil
+}
+
+// getContainerUsage reads a single sample of the stats of the container id.
+func (cli *HyperStatsClient) getContainerUsage(id string) (v1.ResourceList, error) {
+	body, err := cli.Client.ContainerStats(context.Background(), id, false)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var data json.RawMessage
+	if err := json.NewDecoder(body).Decode(&data); err != nil {
+		return nil, err
+	}
+	var stats types.StatsJSON
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, err
+	}
+	// types.CPUStats has no online_cpus, read it on its own
+	var online onlineCPUs
+	if err := json.Unmarshal(data, &online); err != nil {
+		return nil, err
+	}
+	return ContainerUsage(&stats.Stats, online.CPUStats.OnlineCPUs), nil
+}
+
+// onlineCPUs is the number of cpus of a stats sample, which the docker api
+// reports since 1.27
+type onlineCPUs struct {
+	CPUStats struct {
+		OnlineCPUs uint32 `json:"online_cpus"`
+	} `json:"cpu_stats"`
+}
+
+// ContainerUsage returns the cpu and memory usage of a stats sample. The cpu
+// usage is averaged since the previous sample, the memory usage is the
+// working set, i.e. the usage minus the inactive page cache. The cpus are counted
+// from the per cpu usages, or are online when those are empty as on cgroup v2, or 1.
+func ContainerUsage(stats *types.Stats, online uint32) v1.ResourceList {
+	var milliCores int64
+	cpuDelta := float64(stats.CPUStats.CPUUsage.TotalUsage) - float64(stats.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(stats.CPUStats.SystemUsage) - float64(stats.PreCPUStats.SystemUsage)
+	if cpuDelta > 0 && systemDelta > 0 {
+		cpus := float64(len(stats.CPUStats.CPUUsage.PercpuUsage))
+		if cpus == 0 {
+			cpus = float64(online)
+		}
+		if cpus == 0 {
+			cpus = 1
+		}
+		milliCores = int64(cpuDelta / systemDelta * cpus * 1000)
+	}
+
+	workingSet := stats.MemoryStats.Usage
+	if inactive, found := stats.MemoryStats.Stats["total_inactive_file"]; found && inactive < workingSet {
+		workingSet -= inactive
+	}
+
+	return v1.ResourceList{
+		v1.ResourceCPU:    *resource.NewMilliQuantity(milliCores, resource.DecimalSI),
+		v1.ResourceMemory: *resource.NewQuantity(int64(workingSet), resource.BinarySI),
+	}
+}
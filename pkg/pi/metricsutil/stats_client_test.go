@@ -0,0 +1,143 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricsutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	hyperclient "github.com/hyperhq/hyper-api/client"
+	"github.com/hyperhq/hyper-api/types"
+
+	"k8s.io/api/core/v1"
+	api "k8s.io/kubernetes/pkg/apis/core"
+)
+
+func TestContainerUsage(t *testing.T) {
+	tests := []struct {
+		name      string
+		percpu    []uint64
+		online    uint32
+		cpuDelta  uint64
+		usage     uint64
+		inactive  map[string]uint64
+		expectCPU string
+		expectMem string
+	}{
+		{
+			name:      "per cpu usages",
+			percpu:    []uint64{1, 1},
+			online:    4,
+			cpuDelta:  250,
+			usage:     1024 * 1024,
+			expectCPU: "500m",
+			expectMem: "1Mi",
+		},
+		{
+			name:      "online cpus without per cpu usages",
+			online:    4,
+			cpuDelta:  250,
+			usage:     2 * 1024 * 1024,
+			inactive:  map[string]uint64{"total_inactive_file": 1024 * 1024},
+			expectCPU: "1",
+			expectMem: "1Mi",
+		},
+		{
+			name:      "neither per cpu usages nor online cpus",
+			cpuDelta:  250,
+			usage:     1024 * 1024,
+			inactive:  map[string]uint64{"total_inactive_file": 2 * 1024 * 1024},
+			expectCPU: "250m",
+			expectMem: "1Mi",
+		},
+		{
+			name:      "first sample",
+			online:    4,
+			usage:     1024 * 1024,
+			expectCPU: "0",
+			expectMem: "1Mi",
+		},
+	}
+	for _, test := range tests {
+		stats := &types.Stats{}
+		stats.PreCPUStats.CPUUsage.TotalUsage = 1000
+		stats.PreCPUStats.SystemUsage = 10000
+		stats.CPUStats.CPUUsage.TotalUsage = 1000 + test.cpuDelta
+		stats.CPUStats.CPUUsage.PercpuUsage = test.percpu
+		stats.CPUStats.SystemUsage = 11000
+		stats.MemoryStats.Usage = test.usage
+		stats.MemoryStats.Stats = test.inactive
+
+		usage := ContainerUsage(stats, test.online)
+		if cpu := usage[v1.ResourceCPU]; cpu.String() != test.expectCPU {
+			t.Errorf("%s: expected cpu %s, saw %s", test.name, test.expectCPU, cpu.String())
+		}
+		if mem := usage[v1.ResourceMemory]; mem.String() != test.expectMem {
+			t.Errorf("%s: expected memory %s, saw %s", test.name, test.expectMem, mem.String())
+		}
+	}
+}
+
+// fakeStatsClient returns the stats of the containers, or an error for the others
+type fakeStatsClient struct {
+	hyperclient.APIClient
+	stats map[string]string
+}
+
+func (c *fakeStatsClient) ContainerStats(ctx context.Context, container string, stream bool) (io.ReadCloser, error) {
+	if stats, found := c.stats[container]; found {
+		return ioutil.NopCloser(strings.NewReader(stats)), nil
+	}
+	return nil, fmt.Errorf("no such container: %s", container)
+}
+
+func TestGetPodMetrics(t *testing.T) {
+	running := api.ContainerState{Running: &api.ContainerStateRunning{}}
+	pod := api.Pod{}
+	pod.Name = "web"
+	pod.Status.ContainerStatuses = []api.ContainerStatus{
+		{Name: "sidecar", ContainerID: "hyper://2", State: running},
+		{Name: "stopped", ContainerID: "hyper://3", State: running},
+		{Name: "app", ContainerID: "hyper://1", State: running},
+		{Name: "waiting", State: api.ContainerState{Waiting: &api.ContainerStateWaiting{}}},
+	}
+	sample := `{"cpu_stats":{"cpu_usage":{"total_usage":2000},"system_cpu_usage":2000,"online_cpus":2},"precpu_stats":{"cpu_usage":{"total_usage":1000},"system_cpu_usage":1000},"memory_stats":{"usage":1048576}}`
+	client := &fakeStatsClient{stats: map[string]string{"1": sample, "2": sample}}
+	errOut := &bytes.Buffer{}
+
+	metrics, err := NewHyperStatsClient(client, errOut).GetPodMetrics([]api.Pod{pod})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metrics) != 1 || len(metrics[0].Containers) != 2 {
+		t.Fatalf("expected the metrics of 2 containers, saw %#v", metrics)
+	}
+	if names := metrics[0].Containers[0].Name + "," + metrics[0].Containers[1].Name; names != "app,sidecar" {
+		t.Errorf("expected the containers app,sidecar, saw %s", names)
+	}
+	if cpu := metrics[0].Containers[0].Usage[v1.ResourceCPU]; cpu.String() != "2" {
+		t.Errorf("expected cpu 2 from the online cpus, saw %s", cpu.String())
+	}
+	if !strings.Contains(errOut.String(), "warning: could not read the stats of container stopped of pod web") {
+		t.Errorf("expected a warning about the stopped container, saw %q", errOut.String())
+	}
+}
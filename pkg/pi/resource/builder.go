@@ -113,6 +113,9 @@ func IsUsageError(err error) bool {
 type FilenameOptions struct {
 	Filenames []string
 	Recursive bool
+	// Params are the values of the template references in the files, the files
+	// are not templates if nil
+	Params map[string]string
 }
 
 type resourceTuple struct {
@@ -138,6 +141,8 @@ func (b *Builder) Schema(schema validation.Schema) *Builder {
 }
 
 // FilenameParam groups input in two categories: URLs and files (files, directories, STDIN)
+// The files are expanded as templates with the params of filenameOptions if set, the
+// template files (*.tpl.yaml, *.tpl.yml and *.tpl.json) always are.
 // If enforceNamespace is false, namespaces in the specs will be allowed to
 // override the default namespace. If it is true, namespaces that don't match
 // will cause an error.
@@ -146,6 +151,7 @@ func (b *Builder) Schema(schema validation.Schema) *Builder {
 func (b *Builder) FilenameParam(enforceNamespace bool, filenameOptions *FilenameOptions) *Builder {
 	recursive := filenameOptions.Recursive
 	paths := filenameOptions.Filenames
+	start := len(b.paths)
 	for _, s := range paths {
 		switch {
 		case s == "-":
@@ -165,6 +171,15 @@ func (b *Builder) FilenameParam(enforceNamespace bool, filenameOptions *Filename
 		}
 	}
 
+	for _, visitor := range b.paths[start:] {
+		switch visitor := visitor.(type) {
+		case *FileVisitor:
+			visitor.Params = templateParams(visitor.Path, filenameOptions.Params)
+		case *URLVisitor:
+			visitor.Params = templateParams(visitor.URL.Path, filenameOptions.Params)
+		}
+	}
+
 	if enforceNamespace {
 		b.RequireNamespace()
 	}
@@ -172,6 +187,15 @@ func (b *Builder) FilenameParam(enforceNamespace bool, filenameOptions *Filename
 	return b
 }
 
+// templateParams returns the params the file of path is expanded with, a template
+// file is expanded even if no param is set so that its references are resolved
+func templateParams(path string, params map[string]string) map[string]string {
+	if params == nil && IsTemplate(path) {
+		return map[string]string{}
+	}
+	return params
+}
+
 // Unstructured updates the builder so that it will request and send unstructured
 // objects. Unstructured objects preserve all fields sent by the server in a map format
 // based on the object's JSON structure which means no data is lost when the client
@@ -208,6 +232,21 @@ func (b *Builder) Internal() *Builder {
 	return b
 }
 
+// HyperObjects accepts the Volume and FloatingIP pseudo-kinds in the files, with
+// a nil Client, see IsHyperObject. Only commands handling them through the Hyper
+// API opt in, the other commands get an error for these documents. It must be
+// called after the mapper is selected and before the filenames are set.
+func (b *Builder) HyperObjects() *Builder {
+	if b.mapper == nil || len(b.paths) > 0 {
+		b.errs = append(b.errs, fmt.Errorf("HyperObjects must be called after the mapper is selected, and before the filenames are set"))
+		return b
+	}
+	mapper := *b.mapper
+	mapper.hyperObjects = true
+	b.mapper = &mapper
+	return b
+}
+
 // LocalParam calls Local() if local is true.
 func (b *Builder) LocalParam(local bool) *Builder {
 	if local {
@@ -0,0 +1,87 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// VolumeKind is the pseudo-kind of a Hyper volume in a manifest
+	VolumeKind = "Volume"
+	// FloatingIPKind is the pseudo-kind of a Hyper fip in a manifest
+	FloatingIPKind = "FloatingIP"
+)
+
+// hyperResources are the resources of the pseudo-kinds, which are not served by the
+// Kubernetes API but by the Hyper API
+var hyperResources = map[string]string{
+	VolumeKind:     "volumes",
+	FloatingIPKind: "fips",
+}
+
+// hyperMapping returns the mapping of a pseudo-kind of the core group. The mapping
+// has no REST client, the objects are created and deleted through the Hyper API.
+func hyperMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, bool) {
+	resource, found := hyperResources[gvk.Kind]
+	if !found || len(gvk.Group) > 0 {
+		return nil, false
+	}
+	return &meta.RESTMapping{
+		Resource:         resource,
+		GroupVersionKind: gvk,
+		Scope:            meta.RESTScopeRoot,
+		MetadataAccessor: meta.NewAccessor(),
+	}, true
+}
+
+// IsHyperObject returns true if info is a Volume or a FloatingIP of a manifest
+func IsHyperObject(info *Info) bool {
+	if info.Mapping == nil || info.Client != nil {
+		return false
+	}
+	_, found := hyperResources[info.Mapping.GroupVersionKind.Kind]
+	return found
+}
+
+// dependencyOrder is the order the kinds are created in, the volumes and fips are
+// created before the pods and services using them, and the secrets before the pods
+var dependencyOrder = map[string]int{
+	VolumeKind:     0,
+	FloatingIPKind: 1,
+	"Secret":       2,
+}
+
+// SortByDependency sorts infos in the order they should be created in, the order of
+// the infos of the same kind is kept. Reverse the order to delete them.
+func SortByDependency(infos []*Info) {
+	order := func(info *Info) int {
+		if info.Mapping == nil {
+			return len(dependencyOrder)
+		}
+		if n, found := dependencyOrder[info.Mapping.GroupVersionKind.Kind]; found {
+			return n
+		}
+		return len(dependencyOrder)
+	}
+	sort.SliceStable(infos, func(i, j int) bool {
+		return order(infos[i]) < order(infos[j])
+	})
+}
@@ -32,6 +32,9 @@ type Mapper struct {
 	meta.RESTMapper
 	ClientMapper
 	runtime.Decoder
+
+	// hyperObjects accepts the Volume and FloatingIP pseudo-kinds, see Builder.HyperObjects
+	hyperObjects bool
 }
 
 // AcceptUnrecognizedObjects will return a mapper that will tolerate objects
@@ -55,13 +58,18 @@ func (m *Mapper) InfoForData(data []byte, source string) (*Info, error) {
 		return nil, fmt.Errorf("unable to decode %q: %v", source, err)
 	}
 
+	var client RESTClient
 	mapping, err := m.RESTMapping(gvk.GroupKind(), gvk.Version)
 	if err != nil {
-		return nil, fmt.Errorf("unable to recognize %q: %v", source, err)
-	}
-
-	client, err := m.ClientForMapping(mapping)
-	if err != nil {
+		// the volumes and fips of a manifest have no client, see IsHyperObject
+		var found bool
+		if mapping, found = hyperMapping(*gvk); !found {
+			return nil, fmt.Errorf("unable to recognize %q: %v", source, err)
+		}
+		if !m.hyperObjects {
+			return nil, fmt.Errorf("unable to handle %q: %s objects are only supported by create and delete", source, gvk.Kind)
+		}
+	} else if client, err = m.ClientForMapping(mapping); err != nil {
 		return nil, fmt.Errorf("unable to connect to a server to handle %q: %v", mapping.Resource, err)
 	}
 
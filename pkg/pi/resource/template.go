@@ -0,0 +1,260 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	ghodssyaml "github.com/ghodss/yaml"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// templateReference matches $$, $NAME and ${NAME}
+var templateReference = regexp.MustCompile(`\$(\$|[A-Za-z_][A-Za-z0-9_]*|\{[A-Za-z_][A-Za-z0-9_]*\})`)
+
+// templateExtensions are the extensions of the files which are always expanded as
+// templates, even without params
+var templateExtensions = []string{".tpl.yaml", ".tpl.yml", ".tpl.json"}
+
+// IsTemplate returns true if path is the path of a template file
+func IsTemplate(path string) bool {
+	for _, ext := range templateExtensions {
+		if strings.HasSuffix(path, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandTemplate replaces the $NAME and ${NAME} references in the documents read
+// from r with the values of params, $$ is a literal $. It fails if a reference has
+// no value. The values referenced in the data of a Secret are base64 encoded, so
+// the template holds the plain value. The documents are returned as a JSON stream.
+func ExpandTemplate(r io.Reader, params map[string]string) ([]byte, error) {
+	var out bytes.Buffer
+	reader := yaml.NewYAMLReader(bufio.NewReader(r))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		data, err := expandDocument(doc, params)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+		out.Write(data)
+		out.WriteString("\n")
+	}
+	return out.Bytes(), nil
+}
+
+// templateExpansion replaces the references of a document with placeholders before
+// it is parsed, and the placeholders of the parsed document with the values of the
+// references, so that a value is never parsed as YAML. A placeholder is a hex
+// number, thus a reference which is a whole plain scalar is parsed as a number and
+// replaced with a number or a boolean if its value is one, as it was written in the
+// document. The references in comments are dropped with the comments.
+type templateExpansion struct {
+	params     map[string]string
+	names      []string
+	unresolved map[string]bool
+}
+
+// placeholderBase is the placeholder of the first reference, the placeholders of
+// the references of a document have the same length so that none is a prefix of
+// another
+const placeholderBase = 0x7e57ab1e0000
+
+// placeholder returns the placeholder of the reference of index
+func placeholder(index int) string {
+	return fmt.Sprintf("%#x", placeholderBase+index)
+}
+
+// placeholders replaces the references of doc with placeholders
+func (e *templateExpansion) placeholders(doc string) string {
+	return templateReference.ReplaceAllStringFunc(doc, func(reference string) string {
+		name := strings.TrimSuffix(strings.TrimPrefix(reference[1:], "{"), "}")
+		if name == "$" {
+			return "$"
+		}
+		e.names = append(e.names, name)
+		return placeholder(len(e.names) - 1)
+	})
+}
+
+// value returns the value of the reference of index
+func (e *templateExpansion) value(index int) string {
+	name := e.names[index]
+	value, found := e.params[name]
+	if !found {
+		e.unresolved[name] = true
+	}
+	return value
+}
+
+// hasPlaceholder returns true if s holds the placeholder of a reference
+func (e *templateExpansion) hasPlaceholder(s string) bool {
+	for index := range e.names {
+		if strings.Contains(s, placeholder(index)) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandString replaces the placeholders in s with the values of their references
+func (e *templateExpansion) expandString(s string) string {
+	for index := range e.names {
+		if strings.Contains(s, placeholder(index)) {
+			s = strings.Replace(s, placeholder(index), e.value(index), -1)
+		}
+	}
+	return s
+}
+
+// expandNumber returns the value of the reference of the placeholder n, or n if it
+// is not a placeholder. The value is a number or a boolean if it is one.
+func (e *templateExpansion) expandNumber(n json.Number) interface{} {
+	index, ok := e.placeholderIndex(n.String())
+	if !ok {
+		return n
+	}
+	value := e.value(index)
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var typed interface{}
+	if err := decoder.Decode(&typed); err == nil && !decoder.More() {
+		switch typed.(type) {
+		case json.Number, bool:
+			return typed
+		}
+	}
+	return value
+}
+
+// placeholderIndex returns the index of the reference of a placeholder parsed as a
+// decimal number
+func (e *templateExpansion) placeholderIndex(s string) (int, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < placeholderBase || n >= placeholderBase+int64(len(e.names)) {
+		return 0, false
+	}
+	return int(n - placeholderBase), true
+}
+
+// expandValue replaces the placeholders in the keys and the values of obj
+func (e *templateExpansion) expandValue(obj interface{}) interface{} {
+	switch obj := obj.(type) {
+	case map[string]interface{}:
+		expanded := map[string]interface{}{}
+		for key, value := range obj {
+			if index, ok := e.placeholderIndex(key); ok {
+				key = e.value(index)
+			}
+			expanded[e.expandString(key)] = e.expandValue(value)
+		}
+		return expanded
+	case []interface{}:
+		for i := range obj {
+			obj[i] = e.expandValue(obj[i])
+		}
+		return obj
+	case string:
+		return e.expandString(obj)
+	case json.Number:
+		return e.expandNumber(obj)
+	}
+	return obj
+}
+
+// expandDocument expands a single YAML or JSON document into JSON, it returns nil
+// for an empty document
+func expandDocument(doc []byte, params map[string]string) ([]byte, error) {
+	e := &templateExpansion{params: params, unresolved: map[string]bool{}}
+	data, err := ghodssyaml.YAMLToJSON([]byte(e.placeholders(string(doc))))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template: %v", err)
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var obj interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&obj); err != nil {
+		return nil, err
+	}
+	keys := e.secretDataReferences(obj)
+	obj = e.expandValue(obj)
+	if len(e.unresolved) > 0 {
+		names := []string{}
+		for name := range e.unresolved {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unresolved template parameters: %s", strings.Join(names, ", "))
+	}
+
+	if len(keys) > 0 {
+		secretData := obj.(map[string]interface{})["data"].(map[string]interface{})
+		for _, key := range keys {
+			secretData[key] = base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(secretData[key])))
+		}
+	}
+	return json.Marshal(obj)
+}
+
+// secretDataReferences returns the keys of the data of a Secret whose values
+// hold a reference in the unexpanded document
+func (e *templateExpansion) secretDataReferences(obj interface{}) []string {
+	m, ok := obj.(map[string]interface{})
+	if !ok || m["kind"] != "Secret" {
+		return nil
+	}
+	data, _ := m["data"].(map[string]interface{})
+	keys := []string{}
+	for key, value := range data {
+		switch value := value.(type) {
+		case string:
+			if e.hasPlaceholder(value) {
+				keys = append(keys, key)
+			}
+		case json.Number:
+			if _, ok := e.placeholderIndex(value.String()); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package resource
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	tests := []struct {
+		name        string
+		template    string
+		params      map[string]string
+		expected    string
+		expectedErr string
+	}{
+		{
+			name: "service",
+			template: `kind: Service
+spec:
+  loadBalancerIP: ${FIP}
+  ports:
+  - port: $PORT
+`,
+			params:   map[string]string{"FIP": "1.2.3.4", "PORT": "80"},
+			expected: `{"kind":"Service","spec":{"loadBalancerIP":"1.2.3.4","ports":[{"port":80}]}}` + "\n",
+		},
+		{
+			name: "secret data is base64 encoded",
+			template: `kind: Secret
+data:
+  password: ${PASSWORD}
+  user: dXNlcg==
+stringData:
+  token: $PASSWORD
+`,
+			params:   map[string]string{"PASSWORD": "abcd1234"},
+			expected: `{"data":{"password":"YWJjZDEyMzQ=","user":"dXNlcg=="},"kind":"Secret","stringData":{"token":"abcd1234"}}` + "\n",
+		},
+		{
+			name: "multiple documents",
+			template: `kind: Pod
+metadata:
+  name: $NAME
+---
+# empty
+---
+kind: Service
+metadata:
+  name: $NAME
+`,
+			params:   map[string]string{"NAME": "web"},
+			expected: `{"kind":"Pod","metadata":{"name":"web"}}` + "\n" + `{"kind":"Service","metadata":{"name":"web"}}` + "\n",
+		},
+		{
+			name: "escaped reference",
+			template: `kind: Pod
+command: "echo $$HOME"
+`,
+			params:   map[string]string{},
+			expected: `{"command":"echo $HOME","kind":"Pod"}` + "\n",
+		},
+		{
+			name: "values are not parsed as yaml",
+			template: `kind: Pod
+metadata:
+  name: $NAME
+  labels:
+    app: "${APP}"
+    tier: '$TIER'
+command: echo $CMD
+`,
+			params:   map[string]string{"NAME": "web: true", "APP": "a\"b\nc", "TIER": "*front", "CMD": "x # y"},
+			expected: `{"command":"echo x # y","kind":"Pod","metadata":{"labels":{"app":"a\"b\nc","tier":"*front"},"name":"web: true"}}` + "\n",
+		},
+		{
+			name: "typed values",
+			template: `kind: Pod
+spec:
+  hostNetwork: $HOST
+  replicas: $N
+  env:
+  - name: PORT
+    value: "$N"
+  - name: VERSION
+    value: $N.$N
+  nodeSelector:
+    $KEY: $N$N
+`,
+			params:   map[string]string{"HOST": "true", "N": "2", "KEY": "zone"},
+			expected: `{"kind":"Pod","spec":{"env":[{"name":"PORT","value":"2"},{"name":"VERSION","value":"2.2"}],"hostNetwork":true,"nodeSelector":{"zone":"22"},"replicas":2}}` + "\n",
+		},
+		{
+			name: "references in comments",
+			template: `# the name is $NAME
+kind: Pod
+metadata:
+  name: web # not $SUFFIX
+`,
+			params:   map[string]string{},
+			expected: `{"kind":"Pod","metadata":{"name":"web"}}` + "\n",
+		},
+		{
+			name:     "json",
+			template: `{"kind": "Service", "spec": {"ports": [{"port": $PORT, "name": "${NAME}"}]}}`,
+			params:   map[string]string{"PORT": "80", "NAME": "http"},
+			expected: `{"kind":"Service","spec":{"ports":[{"name":"http","port":80}]}}` + "\n",
+		},
+		{
+			name: "unresolved references",
+			template: `kind: Pod
+metadata:
+  name: ${NAME}-$SUFFIX
+`,
+			params:      map[string]string{"NAME": "web"},
+			expectedErr: "unresolved template parameters: SUFFIX",
+		},
+	}
+	for _, test := range tests {
+		data, err := ExpandTemplate(strings.NewReader(test.template), test.params)
+		if len(test.expectedErr) > 0 {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("%s: expected error %q, saw %v", test.name, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if string(data) != test.expected {
+			t.Errorf("%s: expected\n%s\nsaw\n%s", test.name, test.expected, data)
+		}
+	}
+}
@@ -557,6 +557,8 @@ type StreamVisitor struct {
 
 	Source string
 	Schema validation.Schema
+	// Params expand the template references of the stream if not nil, see ExpandTemplate
+	Params map[string]string
 }
 
 // NewStreamVisitor is a helper function that is useful when we want to change the fields of the struct but keep calls the same.
@@ -571,7 +573,15 @@ func NewStreamVisitor(r io.Reader, mapper *Mapper, source string, schema validat
 
 // Visit implements Visitor over a stream. StreamVisitor is able to distinct multiple resources in one stream.
 func (v *StreamVisitor) Visit(fn VisitorFunc) error {
-	d := yaml.NewYAMLOrJSONDecoder(v.Reader, 4096)
+	r := v.Reader
+	if v.Params != nil {
+		data, err := ExpandTemplate(v.Reader, v.Params)
+		if err != nil {
+			return fmt.Errorf("error expanding template %q: %v", v.Source, err)
+		}
+		r = bytes.NewReader(data)
+	}
+	d := yaml.NewYAMLOrJSONDecoder(r, 4096)
 	for {
 		ext := runtime.RawExtension{}
 		if err := d.Decode(&ext); err != nil {
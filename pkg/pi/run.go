@@ -241,6 +241,27 @@ func getImagePullSecrets(params map[string]string) ([]v1.LocalObjectReference, e
 	return res, nil
 }
 
+// SecurityGroupLabelPrefix prefixes the labels that add a pod to a security
+// group, e.g. sh_hyper_sgroup_web=yes. This is the label documented by the
+// "Security Group" page of the Hyper.sh docs, and set by 'hyper run --sg'.
+const SecurityGroupLabelPrefix = "sh_hyper_sgroup_"
+
+// updateSecurityGroups adds the labels of the comma separated security groups
+// in params to labels.
+func updateSecurityGroups(params map[string]string, labels map[string]string) map[string]string {
+	for _, sg := range strings.Split(params["security-group"], ",") {
+		sg = strings.TrimSpace(sg)
+		if len(sg) == 0 {
+			continue
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[SecurityGroupLabelPrefix+sg] = "yes"
+	}
+	return labels
+}
+
 // getName returns the name of newly created resource.
 func getName(params map[string]string) (string, error) {
 	name, found := params["name"]
@@ -348,6 +369,7 @@ func (JobV1) ParamNames() []GeneratorParam {
 		{"backoff-limit", false},
 		{"active-deadline-seconds", false},
 		{"image-pull-secrets", false},
+		{"security-group", false},
 		{"volume", false},
 	}
 }
@@ -421,6 +443,13 @@ func (JobV1) Generate(genericParams map[string]interface{}) (runtime.Object, err
 	}
 	podSpec.RestartPolicy = restartPolicy
 
+	// the security groups are labels of the pods, not of the job
+	podLabels := map[string]string{}
+	for k, v := range labels {
+		podLabels[k] = v
+	}
+	podLabels = updateSecurityGroups(params, podLabels)
+
 	job := batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   name,
@@ -429,7 +458,7 @@ func (JobV1) Generate(genericParams map[string]interface{}) (runtime.Object, err
 		Spec: batchv1.JobSpec{
 			Template: v1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels: podLabels,
 				},
 				Spec: *podSpec,
 			},
@@ -955,6 +984,7 @@ func (BasicPod) ParamNames() []GeneratorParam {
 		{"active-deadline-seconds", false},
 		{"size", false},
 		{"volume", false},
+		{"security-group", false},
 	}
 }
 
@@ -1064,6 +1094,7 @@ func (BasicPod) Generate(genericParams map[string]interface{}) (runtime.Object,
 	if err := updatePodVolumes(volumes, volumeMounts, &pod.Spec); err != nil {
 		return nil, err
 	}
+	pod.ObjectMeta.Labels = updateSecurityGroups(params, pod.ObjectMeta.Labels)
 
 	return &pod, nil
 }
@@ -0,0 +1,228 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package archive writes and extracts the tar archives 'pi cp' exchanges with
+// the containers.
+package archive
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MakeTar writes the file or directory srcPath to writer as a tar archive,
+// with destName as the name of its top level entry.
+func MakeTar(srcPath, destName string, writer io.Writer) error {
+	tw := tar.NewWriter(writer)
+	err := filepath.Walk(srcPath, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcPath, file)
+		if err != nil {
+			return err
+		}
+
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(file); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = path.Join(destName, filepath.ToSlash(rel))
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// UntarAll extracts the tar archive in reader to destDir. When rename is set,
+// the top level entry srcName is extracted as rename instead.
+func UntarAll(reader io.Reader, destDir, srcName, rename string, errOut io.Writer) error {
+	destDir = filepath.Clean(destDir)
+
+	// directory modes are applied last, so that read only directories can
+	// still be filled
+	dirModes := map[string]os.FileMode{}
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(hdr.Name))
+		if len(rename) != 0 {
+			if name == srcName {
+				name = rename
+			} else if strings.HasPrefix(name, srcName+string(filepath.Separator)) {
+				name = rename + name[len(srcName):]
+			}
+		}
+		target := filepath.Join(destDir, name)
+		if !isInDir(destDir, target) {
+			return fmt.Errorf("illegal file path %q in archive", hdr.Name)
+		}
+		// the symlinks of earlier entries must not lead a write outside of destDir
+		checked := target
+		if hdr.Typeflag == tar.TypeSymlink {
+			checked = filepath.Dir(target)
+		}
+		if err := checkInDir(destDir, checked); err != nil {
+			return fmt.Errorf("illegal file path %q in archive: %v", hdr.Name, err)
+		}
+
+		mode := hdr.FileInfo().Mode()
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+			dirModes[target] = mode.Perm()
+		case tar.TypeReg, tar.TypeRegA:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm())
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+			// the mode given to OpenFile is masked by the umask
+			if err := os.Chmod(target, mode.Perm()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if filepath.IsAbs(hdr.Linkname) {
+				return fmt.Errorf("illegal symlink %q to absolute path %q in archive", hdr.Name, hdr.Linkname)
+			}
+			parent, err := resolvePath(filepath.Dir(target))
+			if err != nil {
+				return err
+			}
+			resolvedDir, err := resolvePath(destDir)
+			if err != nil {
+				return err
+			}
+			if !isInDir(resolvedDir, filepath.Join(parent, hdr.Linkname)) {
+				return fmt.Errorf("illegal symlink %q to %q outside of %s in archive", hdr.Name, hdr.Linkname, destDir)
+			}
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(hdr.Linkname, target); err != nil {
+				return err
+			}
+		default:
+			fmt.Fprintf(errOut, "skipping %s, unsupported file type\n", hdr.Name)
+		}
+	}
+
+	// children sort after their parents, chmod them first
+	dirs := []string{}
+	for dir := range dirModes {
+		dirs = append(dirs, dir)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, dir := range dirs {
+		if err := os.Chmod(dir, dirModes[dir]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isInDir returns true if path is dir or lexically below dir
+func isInDir(dir, path string) bool {
+	return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) || dir == string(filepath.Separator)
+}
+
+// checkInDir returns an error if path, with its symlinks resolved, is not in dir
+// with its symlinks resolved
+func checkInDir(dir, path string) error {
+	resolvedDir, err := resolvePath(dir)
+	if err != nil {
+		return err
+	}
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return err
+	}
+	if !isInDir(resolvedDir, resolved) {
+		return fmt.Errorf("%s resolves to %s, outside of %s", path, resolved, dir)
+	}
+	return nil
+}
+
+// resolvePath evaluates the symlinks of the longest existing prefix of path, and
+// appends the rest of path to it
+func resolvePath(path string) (string, error) {
+	existing, rest := filepath.Clean(path), ""
+	for {
+		if _, err := os.Lstat(existing); err == nil {
+			break
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			break
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+	resolved, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(resolved, rest), nil
+}
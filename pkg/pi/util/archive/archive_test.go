@@ -0,0 +1,180 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package archive
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type tarEntry struct {
+	name     string
+	typeflag byte
+	linkname string
+	content  string
+}
+
+func makeTestTar(t *testing.T, entries []tarEntry) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+	for _, entry := range entries {
+		hdr := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Linkname: entry.linkname,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+		}
+		if entry.typeflag == tar.TypeDir {
+			hdr.Mode = 0755
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
+
+func TestUntarAll(t *testing.T) {
+	tests := []struct {
+		name        string
+		entries     []tarEntry
+		expectErr   bool
+		expectFiles map[string]string
+	}{
+		{
+			name: "files and symlinks inside the destination",
+			entries: []tarEntry{
+				{name: "dir", typeflag: tar.TypeDir},
+				{name: "dir/file", typeflag: tar.TypeReg, content: "hello"},
+				{name: "link", typeflag: tar.TypeSymlink, linkname: "dir"},
+				{name: "link/other", typeflag: tar.TypeReg, content: "world"},
+			},
+			expectFiles: map[string]string{"dir/file": "hello", "dir/other": "world"},
+		},
+		{
+			name: "write through a symlink to an absolute path",
+			entries: []tarEntry{
+				{name: "evil", typeflag: tar.TypeSymlink, linkname: "OUTSIDE"},
+				{name: "evil/authorized_keys", typeflag: tar.TypeReg, content: "key"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "write through a symlink to a relative path outside",
+			entries: []tarEntry{
+				{name: "evil", typeflag: tar.TypeSymlink, linkname: "../outside"},
+				{name: "evil/authorized_keys", typeflag: tar.TypeReg, content: "key"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "symlink escaping through a symlink to the destination",
+			entries: []tarEntry{
+				{name: "dot", typeflag: tar.TypeSymlink, linkname: "."},
+				{name: "dot/evil", typeflag: tar.TypeSymlink, linkname: "../outside"},
+				{name: "evil/authorized_keys", typeflag: tar.TypeReg, content: "key"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "path outside the destination",
+			entries: []tarEntry{
+				{name: "../outside/authorized_keys", typeflag: tar.TypeReg, content: "key"},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "untar")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			outside := filepath.Join(root, "outside")
+			destDir := filepath.Join(root, "dest")
+			for _, dir := range []string{outside, destDir} {
+				if err := os.Mkdir(dir, 0755); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i := range test.entries {
+				if test.entries[i].linkname == "OUTSIDE" {
+					test.entries[i].linkname = outside
+				}
+			}
+
+			err = UntarAll(makeTestTar(t, test.entries), destDir, "", "", ioutil.Discard)
+			if test.expectErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", test.expectErr, err)
+			}
+			written, err := ioutil.ReadDir(outside)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(written) > 0 {
+				t.Errorf("unexpected files written outside of the destination: %v", written[0].Name())
+			}
+			for name, content := range test.expectFiles {
+				data, err := ioutil.ReadFile(filepath.Join(destDir, name))
+				if err != nil {
+					t.Errorf("unexpected error reading %s: %v", name, err)
+				} else if string(data) != content {
+					t.Errorf("expected %q in %s, got %q", content, name, string(data))
+				}
+			}
+		})
+	}
+}
+
+func TestUntarAllExistingSymlink(t *testing.T) {
+	root, err := ioutil.TempDir("", "untar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	outside := filepath.Join(root, "outside")
+	destDir := filepath.Join(root, "dest")
+	for _, dir := range []string{outside, destDir} {
+		if err := os.Mkdir(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(destDir, "evil")); err != nil {
+		t.Fatal(err)
+	}
+
+	tarball := makeTestTar(t, []tarEntry{{name: "evil/authorized_keys", typeflag: tar.TypeReg, content: "key"}})
+	if err := UntarAll(tarball, destDir, "", "", ioutil.Discard); err == nil {
+		t.Errorf("expected an error writing through a symlink of the destination")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "authorized_keys")); !os.IsNotExist(err) {
+		t.Errorf("unexpected file written outside of the destination: %v", err)
+	}
+}
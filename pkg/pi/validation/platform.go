@@ -0,0 +1,339 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// The rules checked by PlatformSchema
+const (
+	RuleKind         = "kind"
+	RuleVolumeType   = "volume-type"
+	RuleVolumeCount  = "volume-count"
+	RuleVolumeMount  = "volume-mount"
+	RuleVolumeRef    = "volume-ref"
+	RuleVolumeZone   = "volume-zone"
+	RuleInstanceType = "instance-type"
+	RuleMemoryLimit  = "memory-limit"
+	RuleSecretRef    = "secret-ref"
+)
+
+const (
+	instanceTypeAnnotation = "sh_hyper_instancetype"
+	zoneNodeSelector       = "zone"
+
+	// maxFlexVolumes is the number of Pi volumes a pod may use
+	maxFlexVolumes = 4
+)
+
+var (
+	// supportedKinds are the kinds served by Pi, Volume and FloatingIP are the
+	// pseudo-kinds of the volumes and fips of a stack
+	supportedKinds = sets.NewString("Pod", "Service", "Secret", "Job", "Volume", "FloatingIP")
+
+	// instanceTypes are the sizes of the sh_hyper_instancetype annotation
+	instanceTypes = []string{"s1", "s2", "s3", "s4", "m1", "m2", "m3", "l1", "l2", "l3", "l4", "l5", "l6"}
+
+	// maxMemoryLimit is the largest memory a pod sized by the limits of its containers may use
+	maxMemoryLimit = resource.MustParse("16Gi")
+)
+
+// PlatformError is an object breaking a rule of the Pi platform
+type PlatformError struct {
+	Rule    string
+	Field   string
+	Message string
+}
+
+func (e *PlatformError) Error() string {
+	if len(e.Field) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// PlatformSchema validates objects against the rules the Pi server enforces on top
+// of the schema: the supported kinds and volume types, the instance type or the
+// memory limits of a pod, and the volumes and secrets a pod refers to.
+type PlatformSchema struct {
+	// Volumes are the zones of the volumes known to exist, by name
+	Volumes map[string]string
+	// Secrets are the names of the secrets known to exist
+	Secrets sets.String
+	// Complete is set when Volumes and Secrets list every volume and secret of
+	// the server, a reference to another volume or secret is then an error
+	Complete bool
+}
+
+// ValidateBytes validates bytes, the errors are *PlatformError.
+func (s PlatformSchema) ValidateBytes(data []byte) error {
+	out, err := yaml.ToJSON(data)
+	if err != nil {
+		return err
+	}
+	meta := struct {
+		Kind string `json:"kind"`
+	}{}
+	if err := json.Unmarshal(out, &meta); err != nil {
+		return err
+	}
+
+	var errs []error
+	switch meta.Kind {
+	case "Pod":
+		pod := v1.Pod{}
+		if err := json.Unmarshal(out, &pod); err != nil {
+			return err
+		}
+		errs = s.validatePod(pod.Annotations, &pod.Spec, field.NewPath("spec"))
+	case "Job":
+		job := batchv1.Job{}
+		if err := json.Unmarshal(out, &job); err != nil {
+			return err
+		}
+		annotations := job.Spec.Template.Annotations
+		if size, found := job.Annotations[instanceTypeAnnotation]; found {
+			annotations = map[string]string{instanceTypeAnnotation: size}
+		}
+		errs = s.validatePod(annotations, &job.Spec.Template.Spec, field.NewPath("spec", "template", "spec"))
+	default:
+		if !supportedKinds.Has(meta.Kind) {
+			errs = append(errs, &PlatformError{
+				Rule:    RuleKind,
+				Field:   "kind",
+				Message: fmt.Sprintf("kind %q is not supported, Pi serves %s", meta.Kind, strings.Join(supportedKinds.List(), ", ")),
+			})
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (s PlatformSchema) validatePod(annotations map[string]string, spec *v1.PodSpec, path *field.Path) []error {
+	errs := s.validateVolumes(spec, path)
+	errs = append(errs, validateSize(annotations, spec, path)...)
+	errs = append(errs, s.validateSecretRefs(spec, path)...)
+	return errs
+}
+
+// validateVolumes checks the volume types, the mounts, and that the Pi volumes
+// exist and are in the zone of the pod
+func (s PlatformSchema) validateVolumes(spec *v1.PodSpec, path *field.Path) []error {
+	var errs []error
+	names := sets.NewString()
+	volumeZones := map[string]string{}
+	flexVolumes := 0
+	for i, volume := range spec.Volumes {
+		volumePath := path.Child("volumes").Index(i)
+		names.Insert(volume.Name)
+		switch {
+		case volume.EmptyDir != nil, volume.GitRepo != nil:
+		case volume.Secret != nil:
+			if err := s.validateSecretRef(volume.Secret.SecretName, volumePath.Child("secret", "secretName")); err != nil {
+				errs = append(errs, err)
+			}
+		case volume.FlexVolume != nil:
+			flexVolumes++
+			volumeID := volume.FlexVolume.Options["volumeID"]
+			idPath := volumePath.Child("flexVolume", "options", "volumeID")
+			if len(volumeID) == 0 {
+				errs = append(errs, &PlatformError{Rule: RuleVolumeRef, Field: idPath.String(), Message: "the name of a Pi volume is required"})
+				continue
+			}
+			if zone, found := s.Volumes[volumeID]; found {
+				// a volume of the manifest without zone is created in the default zone
+				if len(zone) > 0 {
+					volumeZones[volumeID] = zone
+				}
+			} else if s.Complete {
+				errs = append(errs, &PlatformError{Rule: RuleVolumeRef, Field: idPath.String(), Message: fmt.Sprintf("volume %q not found", volumeID)})
+			}
+		default:
+			errs = append(errs, &PlatformError{
+				Rule:    RuleVolumeType,
+				Field:   volumePath.String(),
+				Message: fmt.Sprintf("volume %q is not supported, Pi supports emptyDir, secret, gitRepo and flexVolume volumes", volume.Name),
+			})
+		}
+	}
+	if flexVolumes > maxFlexVolumes {
+		errs = append(errs, &PlatformError{
+			Rule:    RuleVolumeCount,
+			Field:   path.Child("volumes").String(),
+			Message: fmt.Sprintf("%d flexVolumes, a pod can use at most %d Pi volumes", flexVolumes, maxFlexVolumes),
+		})
+	}
+
+	containers := map[string][]v1.Container{"initContainers": spec.InitContainers, "containers": spec.Containers}
+	for _, key := range []string{"initContainers", "containers"} {
+		for i, container := range containers[key] {
+			for j, mount := range container.VolumeMounts {
+				if !names.Has(mount.Name) {
+					errs = append(errs, &PlatformError{
+						Rule:    RuleVolumeMount,
+						Field:   path.Child(key).Index(i).Child("volumeMounts").Index(j).Child("name").String(),
+						Message: fmt.Sprintf("volume %q is not a volume of the pod", mount.Name),
+					})
+				}
+			}
+		}
+	}
+
+	podZone := spec.NodeSelector[zoneNodeSelector]
+	zones := sets.NewString()
+	for _, zone := range volumeZones {
+		zones.Insert(zone)
+	}
+	if len(podZone) > 0 {
+		for _, volumeID := range sets.StringKeySet(volumeZones).List() {
+			if zone := volumeZones[volumeID]; zone != podZone {
+				errs = append(errs, &PlatformError{
+					Rule:    RuleVolumeZone,
+					Field:   path.Child("nodeSelector", zoneNodeSelector).String(),
+					Message: fmt.Sprintf("the pod is in zone %s but volume %q is in zone %s", podZone, volumeID, zone),
+				})
+			}
+		}
+	} else if zones.Len() > 1 {
+		errs = append(errs, &PlatformError{
+			Rule:    RuleVolumeZone,
+			Field:   path.Child("volumes").String(),
+			Message: fmt.Sprintf("the volumes are in zones %s, the volumes of a pod must be in the same zone", strings.Join(zones.List(), ", ")),
+		})
+	}
+	return errs
+}
+
+// validateSize checks the instance type, and that the memory limits of the
+// containers fit in the largest instance type
+func validateSize(annotations map[string]string, spec *v1.PodSpec, path *field.Path) []error {
+	var errs []error
+	size, sized := annotations[instanceTypeAnnotation]
+	if sized && !sets.NewString(instanceTypes...).Has(size) {
+		errs = append(errs, &PlatformError{
+			Rule:    RuleInstanceType,
+			Field:   field.NewPath("metadata", "annotations").Key(instanceTypeAnnotation).String(),
+			Message: fmt.Sprintf("unknown instance type %q, expected one of %s", size, strings.Join(instanceTypes, ", ")),
+		})
+	}
+
+	// init containers run one after the other before the containers, the pod
+	// needs the memory of the largest of them or of all the containers
+	limited := false
+	total := resource.Quantity{}
+	for _, container := range spec.Containers {
+		if memory, found := container.Resources.Limits[v1.ResourceMemory]; found {
+			limited = true
+			total.Add(memory)
+		}
+	}
+	for _, container := range spec.InitContainers {
+		if memory, found := container.Resources.Limits[v1.ResourceMemory]; found {
+			limited = true
+			if memory.Cmp(total) > 0 {
+				total = memory
+			}
+		}
+	}
+	if !limited {
+		return errs
+	}
+	if total.Cmp(maxMemoryLimit) > 0 {
+		errs = append(errs, &PlatformError{
+			Rule:    RuleMemoryLimit,
+			Field:   path.Child("containers").String(),
+			Message: fmt.Sprintf("the memory limits of the containers add up to %s, more than the %s of the largest instance type", total.String(), maxMemoryLimit.String()),
+		})
+	}
+	return errs
+}
+
+// validateSecretRefs checks that the image pull secrets and the secrets of the
+// environment of the containers exist
+func (s PlatformSchema) validateSecretRefs(spec *v1.PodSpec, path *field.Path) []error {
+	var errs []error
+	for i, secret := range spec.ImagePullSecrets {
+		if err := s.validateSecretRef(secret.Name, path.Child("imagePullSecrets").Index(i).Child("name")); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	containers := map[string][]v1.Container{"initContainers": spec.InitContainers, "containers": spec.Containers}
+	for _, key := range []string{"initContainers", "containers"} {
+		for i, container := range containers[key] {
+			containerPath := path.Child(key).Index(i)
+			for j, env := range container.Env {
+				if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil || isOptional(env.ValueFrom.SecretKeyRef.Optional) {
+					continue
+				}
+				if err := s.validateSecretRef(env.ValueFrom.SecretKeyRef.Name, containerPath.Child("env").Index(j).Child("valueFrom", "secretKeyRef", "name")); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			for j, env := range container.EnvFrom {
+				if env.SecretRef == nil || isOptional(env.SecretRef.Optional) {
+					continue
+				}
+				if err := s.validateSecretRef(env.SecretRef.Name, containerPath.Child("envFrom").Index(j).Child("secretRef", "name")); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errs
+}
+
+func (s PlatformSchema) validateSecretRef(name string, path *field.Path) error {
+	if !s.Complete || s.Secrets.Has(name) {
+		return nil
+	}
+	return &PlatformError{Rule: RuleSecretRef, Field: path.String(), Message: fmt.Sprintf("secret %q not found", name)}
+}
+
+func isOptional(optional *bool) bool {
+	return optional != nil && *optional
+}
+
+// PlatformErrors returns the *PlatformError of err, and the other errors apart
+func PlatformErrors(err error) ([]*PlatformError, []error) {
+	if err == nil {
+		return nil, nil
+	}
+	var platformErrs []*PlatformError
+	var others []error
+	errs := []error{err}
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		errs = utilerrors.Flatten(agg).Errors()
+	}
+	for _, err := range errs {
+		if platformErr, ok := err.(*PlatformError); ok {
+			platformErrs = append(platformErrs, platformErr)
+		} else {
+			others = append(others, err)
+		}
+	}
+	return platformErrs, others
+}
@@ -0,0 +1,188 @@
+/*
+Copyright 2018 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validation
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestPlatformSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   PlatformSchema
+		manifest string
+		expected []string
+	}{
+		{
+			name: "valid pod",
+			manifest: `kind: Pod
+spec:
+  containers:
+  - name: nginx
+    image: nginx
+    volumeMounts:
+    - name: data
+      mountPath: /data
+  volumes:
+  - name: data
+    flexVolume:
+      options:
+        volumeID: nginx-data
+`,
+		},
+		{
+			name:     "unsupported kind",
+			manifest: `kind: Deployment`,
+			expected: []string{RuleKind},
+		},
+		{
+			name: "hostPath volume",
+			manifest: `kind: Pod
+spec:
+  containers:
+  - name: busybox
+    image: busybox
+  volumes:
+  - name: data
+    hostPath:
+      path: /data
+`,
+			expected: []string{RuleVolumeType},
+		},
+		{
+			name: "memory limits over the largest instance type",
+			manifest: `kind: Pod
+spec:
+  containers:
+  - name: nginx
+    resources:
+      limits:
+        memory: 8Gi
+  - name: mongo
+    resources:
+      limits:
+        memory: 9Gi
+`,
+			expected: []string{RuleMemoryLimit},
+		},
+		{
+			name: "instance type with limits fitting the instance type",
+			manifest: `kind: Job
+metadata:
+  annotations:
+    sh_hyper_instancetype: m1
+spec:
+  template:
+    spec:
+      containers:
+      - name: alpine
+        resources:
+          limits:
+            memory: 1Gi
+`,
+		},
+		{
+			name:   "volume not in the zone of the pod",
+			schema: PlatformSchema{Volumes: map[string]string{"vol-c": "gcp-us-central1-c"}},
+			manifest: `kind: Pod
+spec:
+  nodeSelector:
+    zone: gcp-us-central1-b
+  containers:
+  - name: nginx
+  volumes:
+  - name: data
+    flexVolume:
+      options:
+        volumeID: vol-c
+`,
+			expected: []string{RuleVolumeZone},
+		},
+		{
+			name:   "missing secret and volume",
+			schema: PlatformSchema{Volumes: map[string]string{}, Secrets: sets.NewString("regcred"), Complete: true},
+			manifest: `kind: Pod
+spec:
+  imagePullSecrets:
+  - name: regcred
+  - name: regcred-not-exist
+  containers:
+  - name: alpine
+  volumes:
+  - name: data
+    flexVolume:
+      options:
+        volumeID: invalid-vol
+`,
+			expected: []string{RuleVolumeRef, RuleSecretRef},
+		},
+		{
+			name: "references are not checked offline",
+			manifest: `kind: Pod
+spec:
+  imagePullSecrets:
+  - name: regcred-not-exist
+  containers:
+  - name: alpine
+  volumes:
+  - name: data
+    flexVolume:
+      options:
+        volumeID: invalid-vol
+`,
+		},
+		{
+			name: "too many volumes and unknown mount",
+			manifest: `kind: Pod
+spec:
+  containers:
+  - name: nginx
+    volumeMounts:
+    - name: other
+      mountPath: /other
+  volumes:
+  - {name: v1, flexVolume: {options: {volumeID: v1}}}
+  - {name: v2, flexVolume: {options: {volumeID: v2}}}
+  - {name: v3, flexVolume: {options: {volumeID: v3}}}
+  - {name: v4, flexVolume: {options: {volumeID: v4}}}
+  - {name: v5, flexVolume: {options: {volumeID: v5}}}
+`,
+			expected: []string{RuleVolumeCount, RuleVolumeMount},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			platformErrs, errs := PlatformErrors(test.schema.ValidateBytes([]byte(test.manifest)))
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+			rules := []string{}
+			for _, err := range platformErrs {
+				rules = append(rules, err.Rule)
+			}
+			if len(test.expected) == 0 {
+				test.expected = []string{}
+			}
+			if !reflect.DeepEqual(rules, test.expected) {
+				t.Errorf("expected rules %v, got %v: %v", test.expected, rules, platformErrs)
+			}
+		})
+	}
+}
@@ -20,11 +20,28 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/hyperhq/client-go/tools/clientcmd/api/hyper"
-
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// VolumeCreateRequest is the body of a volume creation, like the hyper
+// VolumeCreateRequest, with the snapshot the volume is created from
+type VolumeCreateRequest struct {
+	Name     string `json:"name"`
+	Zone     string `json:"zone"`
+	Size     int    `json:"size,omitempty"`
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+func (v *VolumeCreateRequest) GetObjectKind() schema.ObjectKind {
+	return schema.EmptyObjectKind
+}
+
+func (v *VolumeCreateRequest) DeepCopyObject() runtime.Object {
+	out := *v
+	return &out
+}
+
 // VolumeGeneratorV1 supports stable generation of an opaque volume
 type VolumeGeneratorV1 struct {
 	// Name of volume (required)
@@ -33,6 +50,8 @@ type VolumeGeneratorV1 struct {
 	Size string
 	// Zone of the volume (optional)
 	Zone string
+	// Snapshot to create the volume from (optional)
+	Snapshot string
 }
 
 // Generate returns a volume using the specified parameters
@@ -53,6 +72,7 @@ func (s VolumeGeneratorV1) Generate(genericParams map[string]interface{}) (runti
 	delegate.Name = params["name"]
 	delegate.Size = params["size"]
 	delegate.Zone = params["zone"]
+	delegate.Snapshot = params["snapshot"]
 
 	return delegate.StructuredGenerate()
 }
@@ -63,6 +83,7 @@ func (s VolumeGeneratorV1) ParamNames() []GeneratorParam {
 		{"name", true},
 		{"size", false},
 		{"zone", false},
+		{"snapshot", false},
 	}
 }
 
@@ -72,7 +93,7 @@ func (s VolumeGeneratorV1) StructuredGenerate() (runtime.Object, error) {
 	if err = s.validate(); err != nil {
 		return nil, err
 	}
-	volume := &hyper.VolumeCreateRequest{}
+	volume := &VolumeCreateRequest{}
 	volume.Name = s.Name
 	if s.Size != "" {
 		if volume.Size, err = strconv.Atoi(s.Size); err != nil {
@@ -82,6 +103,7 @@ func (s VolumeGeneratorV1) StructuredGenerate() (runtime.Object, error) {
 	if s.Zone != "" {
 		volume.Zone = s.Zone
 	}
+	volume.Snapshot = s.Snapshot
 	return volume, nil
 }
 